@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/rclone"
+)
+
+var filenameModeLabels = map[string]string{
+	"standard":  "Estandar (nombres cifrados)",
+	"obfuscate": "Ofuscado (ligero)",
+	"off":       "Sin cifrar nombres",
+}
+
+// ShowCryptWizard crea una carpeta cifrada (remote crypt) sobre un remote existente
+func ShowCryptWizard(w fyne.Window) {
+	remotes, _ := rclone.ListRemotes()
+	if len(remotes) == 0 {
+		dialog.ShowInformation("Cifrar carpeta", "Primero configura una unidad sobre la que cifrar.", w)
+		return
+	}
+
+	entryName := widget.NewEntry()
+	entryName.PlaceHolder = "Ej: Privado"
+	entryName.Validator = rclone.NewRemoteNameValidator()
+
+	selectBase := widget.NewSelect(remotes, nil)
+	selectBase.SetSelectedIndex(0)
+
+	entryPath := widget.NewEntry()
+	entryPath.Text = "cifrado"
+
+	var modeOptions []string
+	for _, m := range rclone.FilenameEncryptionModes {
+		modeOptions = append(modeOptions, filenameModeLabels[m])
+	}
+	selectMode := widget.NewSelect(modeOptions, nil)
+	selectMode.SetSelectedIndex(0)
+
+	checkDirs := widget.NewCheck("Cifrar tambien nombres de carpetas", nil)
+	checkDirs.Checked = true
+
+	entryPass := widget.NewPasswordEntry()
+	entryPass.Text = rclone.GenerateSecret()
+	entrySalt := widget.NewPasswordEntry()
+	entrySalt.Text = rclone.GenerateSecret()
+	btnRegen := widget.NewButtonWithIcon("Generar claves nuevas", theme.ViewRefreshIcon(), func() {
+		entryPass.SetText(rclone.GenerateSecret())
+		entrySalt.SetText(rclone.GenerateSecret())
+	})
+
+	d := dialog.NewForm("Cifrar una carpeta", "Crear", "Cancelar", []*widget.FormItem{
+		widget.NewFormItem("Nombre:", entryName),
+		widget.NewFormItem("Unidad base:", selectBase),
+		widget.NewFormItem("Carpeta:", entryPath),
+		widget.NewFormItem("Nombres:", selectMode),
+		widget.NewFormItem("", checkDirs),
+		widget.NewFormItem("Contraseña:", entryPass),
+		widget.NewFormItem("Sal:", entrySalt),
+		widget.NewFormItem("", btnRegen),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		cfg := rclone.CryptConfig{
+			Name:               entryName.Text,
+			Base:               selectBase.Selected,
+			Path:               entryPath.Text,
+			Password:           entryPass.Text,
+			Salt:               entrySalt.Text,
+			FilenameEncryption: rclone.FilenameEncryptionModes[selectMode.SelectedIndex()],
+			DirectoryNames:     checkDirs.Checked,
+		}
+		w.SetContent(container.NewVBox(layout.NewSpacer(), widget.NewLabel("Creando carpeta cifrada..."), widget.NewProgressBarInfinite(), layout.NewSpacer()))
+		go func() {
+			err := rclone.CreateCrypt(cfg)
+			fyne.Do(func() {
+				if err != nil {
+					ShowCloudSelection(w)
+					dialog.ShowError(err, w)
+					return
+				}
+				showRecoverySheet(w, cfg)
+			})
+		}()
+	}, w)
+	d.Resize(fyne.NewSize(550, 500))
+	d.Show()
+}
+
+// showRecoverySheet muestra las claves y permite exportarlas a un archivo
+func showRecoverySheet(w fyne.Window, cfg rclone.CryptConfig) {
+	sheet := rclone.CryptRecoverySheet(cfg)
+
+	text := widget.NewMultiLineEntry()
+	text.SetText(sheet)
+	text.TextStyle = fyne.TextStyle{Monospace: true}
+	text.Wrapping = fyne.TextWrapOff
+	text.SetMinRowsVisible(14)
+
+	btnSave := widget.NewButtonWithIcon("Exportar hoja de recuperacion", theme.DocumentSaveIcon(), func() {
+		save := dialog.NewFileSave(func(uc fyne.URIWriteCloser, err error) {
+			if err != nil || uc == nil {
+				return
+			}
+			defer uc.Close()
+			if _, err := uc.Write([]byte(sheet)); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			// La hoja contiene las claves: solo legible por el usuario
+			os.Chmod(uc.URI().Path(), 0600)
+		}, w)
+		save.SetFileName(fmt.Sprintf("%s-recuperacion.txt", rclone.MountDirName(cfg.Name)))
+		save.SetFilter(storage.NewExtensionFileFilter([]string{".txt"}))
+		save.Show()
+	})
+
+	content := container.NewBorder(
+		widget.NewLabel("Guarda estas claves en un lugar seguro. Sin ellas no podras descifrar tus archivos."),
+		btnSave, nil, nil,
+		container.NewVScroll(text),
+	)
+
+	d := dialog.NewCustom("Carpeta cifrada creada", "Hecho", content, w)
+	d.SetOnClosed(func() { ShowDashboard(w) })
+	d.Resize(fyne.NewSize(700, 500))
+	d.Show()
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/rclone"
+	"github.com/anabasasoft/cloudmount-wizard/internal/settings"
+)
+
+const (
+	driveMyDrive      = "Mi unidad"
+	driveSharedWithMe = "Compartido conmigo"
+	driveTeamPrefix   = "Unidad compartida: "
+)
+
+// ShowDriveOptions recorre una cuenta de Google Drive (mi unidad, compartido
+// conmigo y unidades compartidas) y crea una unidad nueva con lo elegido
+func ShowDriveOptions(w fyne.Window, name string) {
+	var view rclone.DriveView
+	var viewLabel string
+	var drives []rclone.SharedDrive
+	var folders []rclone.DriveFolder
+	var trail []rclone.DriveFolder // Carpetas abiertas desde la raíz de la vista
+	var generation int             // Descarta listados que llegan tarde
+
+	lblPath := widget.NewLabel("")
+	status := widget.NewLabel("")
+	entryName := widget.NewEntry()
+	entryName.Validator = rclone.NewRemoteNameValidator()
+
+	folderList := widget.NewList(
+		func() int { return len(folders) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(i widget.ListItemID, o fyne.CanvasObject) { o.(*widget.Label).SetText(folders[i].Name + "/") },
+	)
+
+	// refresh vuelve a listar la carpeta actual y propone un nombre
+	refresh := func() {
+		parts := []string{viewLabel}
+		for _, f := range trail {
+			parts = append(parts, f.Name)
+		}
+		lblPath.SetText(strings.Join(parts, " / "))
+		entryName.SetText(name + " - " + parts[len(parts)-1])
+
+		parentID := ""
+		if len(trail) > 0 {
+			parentID = trail[len(trail)-1].ID
+		}
+		folders = nil
+		folderList.UnselectAll()
+		folderList.Refresh()
+		status.SetText("Cargando...")
+		generation++
+		v, gen := view, generation
+		go func() {
+			list, err := rclone.ListDriveFolders(name, v, parentID)
+			fyne.Do(func() {
+				if gen != generation {
+					return
+				}
+				if err != nil {
+					status.SetText(err.Error())
+					return
+				}
+				status.SetText("")
+				folders = list
+				folderList.Refresh()
+			})
+		}()
+	}
+	folderList.OnSelected = func(i widget.ListItemID) {
+		trail = append(trail, folders[i])
+		refresh()
+	}
+	btnUp := widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
+		if len(trail) > 0 {
+			trail = trail[:len(trail)-1]
+			refresh()
+		}
+	})
+
+	selSource := widget.NewSelect([]string{driveMyDrive, driveSharedWithMe}, nil)
+	selSource.OnChanged = func(s string) {
+		view = rclone.DriveView{SharedWithMe: s == driveSharedWithMe}
+		viewLabel = s
+		for _, d := range drives {
+			if s == driveTeamPrefix+d.Name {
+				view.TeamDrive = d.ID
+				viewLabel = d.Name
+			}
+		}
+		trail = nil
+		refresh()
+	}
+	selSource.SetSelected(driveMyDrive)
+
+	// Las unidades compartidas se añaden al selector cuando llegan
+	go func() {
+		list, err := rclone.ListSharedDrives(name)
+		if err != nil || len(list) == 0 {
+			return
+		}
+		fyne.Do(func() {
+			drives = list
+			options := []string{driveMyDrive, driveSharedWithMe}
+			for _, d := range drives {
+				options = append(options, driveTeamPrefix+d.Name)
+			}
+			selSource.Options = options
+			selSource.Refresh()
+		})
+	}()
+
+	btnCreate := widget.NewButtonWithIcon("Crear unidad", theme.ConfirmIcon(), func() {
+		newName := entryName.Text
+		if err := entryName.Validate(); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		overrides := view.Overrides()
+		rootID := ""
+		if len(trail) > 0 {
+			rootID = trail[len(trail)-1].ID
+		}
+		w.SetContent(container.NewVBox(layout.NewSpacer(), widget.NewLabel("Creando "+newName+"..."), widget.NewProgressBarInfinite(), layout.NewSpacer()))
+		go func() {
+			err := rclone.CloneRemote(name, newName, overrides)
+			if err == nil && rootID != "" {
+				opts := settings.GetOptions(newName)
+				opts.RootFolderID = rootID
+				err = settings.SetOptions(newName, opts)
+			}
+			fyne.Do(func() {
+				ShowDashboard(w)
+				if err != nil {
+					dialog.ShowError(err, w)
+				}
+			})
+		}()
+	})
+	btnCreate.Importance = widget.HighImportance
+
+	top := container.NewVBox(
+		widget.NewLabelWithStyle("Google Drive: "+name, fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		widget.NewForm(widget.NewFormItem("Origen:", selSource)),
+		widget.NewSeparator(),
+		container.NewBorder(nil, nil, btnUp, nil, lblPath),
+	)
+	bottom := container.NewVBox(
+		status,
+		widget.NewForm(widget.NewFormItem("Nueva unidad:", entryName)),
+		container.NewHBox(
+			widget.NewButtonWithIcon("Volver", theme.CancelIcon(), func() { ShowDashboard(w) }),
+			layout.NewSpacer(),
+			btnCreate,
+		),
+	)
+	w.SetContent(container.NewBorder(top, bottom, nil, nil, folderList))
+}
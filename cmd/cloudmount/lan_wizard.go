@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/rclone"
+)
+
+// portEntry crea un campo de puerto con validación (vacío = puerto por defecto)
+func portEntry(placeholder string) *widget.Entry {
+	e := widget.NewEntry()
+	e.PlaceHolder = placeholder
+	e.Validator = func(s string) error {
+		if s == "" {
+			return nil
+		}
+		if p, err := strconv.Atoi(s); err != nil || p < 1 || p > 65535 {
+			return fmt.Errorf("puerto no valido")
+		}
+		return nil
+	}
+	return e
+}
+
+// ShowSMBWizard configura una carpeta compartida de Windows/Samba (NAS)
+func ShowSMBWizard(w fyne.Window, finish func(name string, err error)) {
+	entryName := widget.NewEntry()
+	entryName.Validator = rclone.NewRemoteNameValidator()
+	entryHost := widget.NewEntry()
+	entryHost.PlaceHolder = "nas.local o 192.168.1.10"
+	entryPort := portEntry("445")
+	entryUser := widget.NewEntry()
+	entryPass := widget.NewPasswordEntry()
+	entryDomain := widget.NewEntry()
+	entryDomain.Text = "WORKGROUP"
+
+	d := dialog.NewForm("Carpeta compartida SMB", "Ok", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Nombre:", entryName),
+		widget.NewFormItem("Servidor:", entryHost),
+		widget.NewFormItem("Puerto:", entryPort),
+		widget.NewFormItem("Dominio:", entryDomain),
+		widget.NewFormItem("User:", entryUser),
+		widget.NewFormItem("Pass:", entryPass),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		port, _ := strconv.Atoi(entryPort.Text)
+		cfg := rclone.SMBConfig{
+			Name:     entryName.Text,
+			Host:     strings.TrimSpace(entryHost.Text),
+			Port:     port,
+			User:     entryUser.Text,
+			Password: entryPass.Text,
+			Domain:   strings.TrimSpace(entryDomain.Text),
+		}
+		go func() { finish(cfg.Name, rclone.CreateSMB(cfg)) }()
+	}, w)
+	d.Resize(fyne.NewSize(500, 420))
+	d.Show()
+}
+
+var ftpTLSLabels = []string{"Sin cifrar (FTP)", "TLS explicito (FTPES)", "TLS implicito (FTPS)"}
+var ftpTLSModes = []string{rclone.FTPTLSNone, rclone.FTPTLSExplicit, rclone.FTPTLSImplicit}
+
+// ShowFTPWizard configura un servidor FTP o FTPS
+func ShowFTPWizard(w fyne.Window, finish func(name string, err error)) {
+	entryName := widget.NewEntry()
+	entryName.Validator = rclone.NewRemoteNameValidator()
+	entryHost := widget.NewEntry()
+	entryHost.PlaceHolder = "ftp.ejemplo.com"
+	entryPort := portEntry("21")
+	entryUser := widget.NewEntry()
+	entryPass := widget.NewPasswordEntry()
+
+	checkSkip := widget.NewCheck("Aceptar certificado autofirmado", nil)
+	selTLS := widget.NewSelect(ftpTLSLabels, func(sel string) {
+		switch sel {
+		case ftpTLSLabels[0]:
+			entryPort.PlaceHolder = "21"
+			checkSkip.Disable()
+		case ftpTLSLabels[2]:
+			entryPort.PlaceHolder = "990"
+			checkSkip.Enable()
+		default:
+			entryPort.PlaceHolder = "21"
+			checkSkip.Enable()
+		}
+		entryPort.Refresh()
+	})
+	selTLS.SetSelectedIndex(1)
+
+	checkPasv := widget.NewCheck("Modo pasivo clasico (PASV, sin EPSV)", nil)
+
+	d := dialog.NewForm("Servidor FTP", "Ok", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Nombre:", entryName),
+		widget.NewFormItem("Servidor:", entryHost),
+		widget.NewFormItem("Cifrado:", selTLS),
+		widget.NewFormItem("Puerto:", entryPort),
+		widget.NewFormItem("User:", entryUser),
+		widget.NewFormItem("Pass:", entryPass),
+		widget.NewFormItem("", checkPasv),
+		widget.NewFormItem("", checkSkip),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		port, _ := strconv.Atoi(entryPort.Text)
+		cfg := rclone.FTPConfig{
+			Name:        entryName.Text,
+			Host:        strings.TrimSpace(entryHost.Text),
+			Port:        port,
+			User:        entryUser.Text,
+			Password:    entryPass.Text,
+			TLS:         ftpTLSModes[selTLS.SelectedIndex()],
+			DisableEPSV: checkPasv.Checked,
+			SkipVerify:  checkSkip.Checked,
+		}
+		go func() { finish(cfg.Name, rclone.CreateFTP(cfg)) }()
+	}, w)
+	d.Resize(fyne.NewSize(500, 480))
+	d.Show()
+}
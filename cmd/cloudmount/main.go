@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"image/color"
@@ -20,6 +21,7 @@ import (
 	"fyne.io/fyne/v2/widget"
 
 	"github.com/anabasasoft/cloudmount-wizard/internal/mega"
+	"github.com/anabasasoft/cloudmount-wizard/internal/provider"
 	"github.com/anabasasoft/cloudmount-wizard/internal/rclone"
 	"github.com/anabasasoft/cloudmount-wizard/internal/settings"
 	"github.com/anabasasoft/cloudmount-wizard/internal/system"
@@ -32,15 +34,26 @@ var (
 
 func main() {
 	minimizedFlag := flag.Bool("minimized", false, "Iniciar minimizado")
+	configPassFlag := flag.Bool("config-pass", false, "Imprimir la contraseña de rclone.conf desde el llavero (uso interno)")
+	verifyTLSFlag := flag.String("verify-tls", "", "Comprobar el certificado fijado de una unidad (uso interno)")
 	flag.Parse()
 
+	if *configPassFlag {
+		os.Exit(printConfigPassword())
+	}
+	if *verifyTLSFlag != "" {
+		os.Exit(verifyTLS(*verifyTLSFlag))
+	}
+	if exe, err := os.Executable(); err == nil {
+		rclone.SetHelperExecutable(exe)
+	}
+	// rclone instalado por la app vive en ~/.local/bin
+	system.AddLocalBinToPath()
+
 	myApp := app.NewWithID("com.anabasasoft.cloudmount")
 	myApp.SetIcon(resourceIconPng)
 	myApp.Settings().SetTheme(&myTheme{})
 
-	// Persistencia Mega
-	go mega.EnsureDaemon()
-
 	myWindow := myApp.NewWindow("CloudMount Wizard")
 	myWindow.Resize(fyne.NewSize(850, 650))
 
@@ -58,7 +71,7 @@ func main() {
 
 	myWindow.SetCloseIntercept(func() { myWindow.Hide() })
 
-	if system.CheckRclone() {
+	startDashboard := func() {
 		// Mostrar dashboard inmediatamente
 		ShowDashboard(myWindow)
 
@@ -79,12 +92,8 @@ func main() {
 				if opts.MountOnStart {
 					// Lanzar cada montaje en su propia goroutine
 					go func(name string) {
-						// Preparacion especial para Mega
-						if name == "Mega" {
-							_ = mega.EnsureDaemon()
-							time.Sleep(300 * time.Millisecond)
-							_, _ = mega.GetWebDAVURL()
-						}
+						// Preparacion propia del proveedor (MEGAcmd, etc.)
+						_ = provider.For(name).PrepareMount(name)
 
 						// Montar
 						_, _ = rclone.MountRemote(name)
@@ -101,17 +110,18 @@ func main() {
 				ShowDashboard(myWindow)
 			})
 		}()
+	}
+
+	if system.CheckRclone() {
+		// Con rclone.conf cifrado primero hay que conseguir la contraseña
+		if rclone.IsConfigEncrypted() {
+			unlockConfig(myWindow, startDashboard)
+		} else {
+			startDashboard()
+		}
 	} else {
 		// Rclone no instalado
-		content := container.NewVBox(
-			widget.NewLabelWithStyle("Rclone no encontrado", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
-					     widget.NewLabel("Se requiere Rclone para usar esta aplicacion."),
-					     widget.NewButton("Instalar Rclone", func() {
-						     // Aqui iria la logica de instalacion
-						     dialog.ShowInformation("Info", "Funcion de instalacion pendiente", myWindow)
-					     }),
-		)
-		myWindow.SetContent(container.NewCenter(content))
+		showRcloneInstall(myWindow, startDashboard)
 	}
 
 	if *minimizedFlag {
@@ -121,6 +131,61 @@ func main() {
 	}
 }
 
+// showRcloneInstall ofrece instalar rclone en ~/.local/bin (sin root) y,
+// al terminar, sigue con el arranque normal
+func showRcloneInstall(w fyne.Window, onInstalled func()) {
+	status := widget.NewLabel("Se requiere Rclone para usar esta aplicacion.")
+	status.Alignment = fyne.TextAlignCenter
+	bar := widget.NewProgressBar()
+	bar.Hide()
+	barBusy := widget.NewProgressBarInfinite()
+	barBusy.Hide()
+
+	var btnInstall *widget.Button
+	btnInstall = widget.NewButton("Instalar Rclone", func() {
+		btnInstall.Disable()
+		barBusy.Show()
+		go func() {
+			err := system.InstallRclone(func(msg string, fraction float64) {
+				fyne.Do(func() {
+					status.SetText(msg)
+					if fraction < 0 {
+						bar.Hide()
+						barBusy.Show()
+					} else {
+						barBusy.Hide()
+						bar.Show()
+						bar.SetValue(fraction)
+					}
+				})
+			})
+			fyne.Do(func() {
+				if err != nil {
+					status.SetText("No se pudo instalar Rclone.")
+					bar.Hide()
+					barBusy.Hide()
+					btnInstall.Enable()
+					dialog.ShowError(err, w)
+					return
+				}
+				if rclone.IsConfigEncrypted() {
+					unlockConfig(w, onInstalled)
+				} else {
+					onInstalled()
+				}
+			})
+		}()
+	})
+
+	w.SetContent(container.NewCenter(container.NewVBox(
+		widget.NewLabelWithStyle("Rclone no encontrado", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		status,
+		widget.NewLabel("Se descarga la version oficial, se verifica su SHA256\ny se instala en "+system.LocalBinDir()+" (sin root)."),
+		container.NewGridWrap(fyne.NewSize(420, bar.MinSize().Height), container.NewStack(bar, barBusy)),
+		btnInstall,
+	)))
+}
+
 // ShowLogViewer muestra la ventana de logs
 // ShowLogViewer muestra la ventana de logs con selector de unidad
 func ShowLogViewer(w fyne.Window) {
@@ -244,8 +309,9 @@ func ShowDashboard(w fyne.Window) {
 
 	listContainer := container.NewVBox()
 
-	// Obtener lista de nubes
+	// Obtener lista de nubes (y su configuración para enlazar remotes derivados)
 	remotes, _ := rclone.ListRemotes()
+	dump, _ := rclone.DumpConfig()
 
 	// Generar tarjetas para cada nube
 	for _, rName := range remotes {
@@ -254,43 +320,37 @@ func ShowDashboard(w fyne.Window) {
 		isMounted := rclone.IsMounted(mountPath)
 		opts := settings.GetOptions(name)
 
-		isMega := (name == "Mega")
-		displayName := name
-		if isMega {
-			displayName = "MEGA (Oficial)"
-		}
+		prov := provider.For(name)
+		displayName := prov.Label(name)
 
 		// Estado visual
 		statusTxt := "OFF"
 		statusIcon := theme.ContentClearIcon()
 
-		if isMounted {
+		// Token OAuth caducado o revocado: el montaje deja de funcionar aunque siga activo
+		token := rclone.CheckToken(name, dump[name])
+
+		if token.NeedsReauth {
+			statusTxt = "Reautorizacion necesaria"
+			statusIcon = theme.WarningIcon()
+		} else if isMounted {
 			statusTxt = "MONTADO"
 			statusIcon = theme.ConfirmIcon()
-		} else if isMega && mega.IsLoggedIn() {
-			statusTxt = "SESION OK"
-			statusIcon = theme.InfoIcon()
-			go mega.GetWebDAVURL()
 		}
+		statusIconW := widget.NewIcon(statusIcon)
+		statusLabel := widget.NewLabel(statusTxt)
 
 		// Calculo de espacio (asincrono)
 		quotaTxt := binding.NewString()
 		quotaTxt.Set("...")
 		quotaVal := binding.NewFloat()
 
-		if isMounted || (isMega && mega.IsLoggedIn()) {
+		// Union/combine: la cuota es la suma de sus miembros
+		members := rclone.MemberRemotes(dump[name])
+
+		loadQuota := func() {
 			go func() {
-				if isMega {
-					used, total, err := mega.GetSpace()
-					if err == nil && total > 0 {
-						fyne.Do(func() {
-							quotaTxt.Set(fmt.Sprintf("%s / %s", rclone.FormatBytes(used), rclone.FormatBytes(total)))
-							quotaVal.Set(float64(used) / float64(total))
-						})
-						return
-					}
-				}
-				q, err := rclone.GetQuota(name)
+				q, err := prov.Quota(name, dump[name])
 				if err == nil && q.Total > 0 {
 					fyne.Do(func() {
 						quotaTxt.Set(fmt.Sprintf("%s / %s", rclone.FormatBytes(q.Used), rclone.FormatBytes(q.Total)))
@@ -301,16 +361,43 @@ func ShowDashboard(w fyne.Window) {
 				}
 			}()
 		}
+		if isMounted || len(members) > 0 {
+			loadQuota()
+		}
 
 		// Botones de accion
 		btnMount := widget.NewButton("Montar Disco", func() {
 			go func() {
-				if isMega {
-					mega.EnsureDaemon()
-					_, _ = mega.GetWebDAVURL()
+				err := prov.PrepareMount(name)
+				var warn provider.Warning
+				if errors.As(err, &warn) {
+					err = nil
 				}
-				rclone.MountRemote(name)
-				fyne.Do(func() { ShowDashboard(w) })
+				if err == nil {
+					_, err = rclone.MountRemote(name)
+				}
+				fyne.Do(func() {
+					ShowDashboard(w)
+					if err == nil && warn.Msg != "" {
+						dialog.ShowInformation("Aviso", warn.Msg, w)
+					}
+					if rclone.IsTLSError(err) {
+						// Certificado no reconocido: ofrecer confiar en él
+						offerTrustCert(w, name, func() {
+							go func() {
+								_, err := rclone.MountRemote(name)
+								fyne.Do(func() {
+									ShowDashboard(w)
+									if err != nil {
+										dialog.ShowError(err, w)
+									}
+								})
+							}()
+						})
+					} else if err != nil {
+						dialog.ShowError(err, w)
+					}
+				})
 			}()
 		})
 
@@ -352,22 +439,121 @@ func ShowDashboard(w fyne.Window) {
 			checkAutoInfo.Checked = opts.MountOnStart
 			checkAutoInfo.Disable()
 
+			// Los botones que abren otra pantalla cierran antes este dialogo
+			var d dialog.Dialog
 			items := []*widget.FormItem{
 				widget.NewFormItem("Solo Lectura:", checkRead),
 							widget.NewFormItem("Limite Cache:", entryCache),
 							widget.NewFormItem("Ancho Banda:", entryBw),
 							widget.NewFormItem("Estado:", checkAutoInfo),
 			}
+			// Google Drive: formatos de documentos y papelera
+			isDrive := dump[name]["type"] == "drive"
+			entryExport := widget.NewEntry()
+			entryExport.SetText(opts.DriveExportFormats)
+			entryExport.PlaceHolder = rclone.DefaultDriveExportFormats
+			entryImport := widget.NewEntry()
+			entryImport.SetText(opts.DriveImportFormats)
+			entryImport.PlaceHolder = "Ej: odt,ods (vacio = no convertir)"
+			checkSkipGdocs := widget.NewCheck("Ocultar Docs/Sheets/Slides", nil)
+			checkSkipGdocs.Checked = opts.DriveSkipGdocs
+			checkAbuse := widget.NewCheck("Permitir descargar archivos marcados como abuso", nil)
+			checkAbuse.Checked = opts.DriveAcknowledgeAbuse
+			checkTrash := widget.NewCheck("Enviar a la papelera al borrar", nil)
+			checkTrash.Checked = opts.DriveUseTrash == nil || *opts.DriveUseTrash
+
+			if isDrive {
+				items = append(items,
+					widget.NewFormItem("Exportar:", entryExport),
+					widget.NewFormItem("Importar:", entryImport),
+					widget.NewFormItem("", checkSkipGdocs),
+					widget.NewFormItem("", checkAbuse),
+					widget.NewFormItem("", checkTrash),
+				)
+				items = append(items, widget.NewFormItem("Drive:", widget.NewButtonWithIcon("Unidades compartidas y carpetas...", theme.FolderIcon(), func() {
+					d.Hide()
+					ShowDriveOptions(w, name)
+				})))
+			}
+			if prov.ID() == "mega" {
+				items = append(items, widget.NewFormItem("Mega:", widget.NewButtonWithIcon("Usar backend de rclone...", theme.ViewRefreshIcon(), func() {
+					d.Hide()
+					showMegaMigration(w, name)
+				})))
+				items = append(items, widget.NewFormItem("", widget.NewButtonWithIcon("Montar otra carpeta...", theme.FolderIcon(), func() {
+					d.Hide()
+					go func() {
+						account := mega.CurrentAccount()
+						fyne.Do(func() {
+							if account == "" {
+								dialog.ShowError(fmt.Errorf("MEGAcmd no tiene ninguna sesion iniciada"), w)
+								return
+							}
+							showMegaFolders(w, account, "", func() { ShowDashboard(w) })
+						})
+					}()
+				})))
+			}
+			if dump[name]["token"] != "" {
+				items = append(items, widget.NewFormItem("Cuenta:", widget.NewButtonWithIcon("Reconectar...", theme.ViewRefreshIcon(), func() {
+					d.Hide()
+					reconnectRemote(w, name, dump[name]["type"], isMounted)
+				})))
+			}
+			if dump[name]["type"] == "onedrive" {
+				items = append(items, widget.NewFormItem("OneDrive:", widget.NewButtonWithIcon("Bibliotecas...", theme.FolderIcon(), func() {
+					d.Hide()
+					ShowOneDriveDrives(w, name, func(_ string, err error) {
+						fyne.Do(func() {
+							ShowDashboard(w)
+							if err != nil {
+								dialog.ShowError(err, w)
+							}
+						})
+					})
+				})))
+			}
+			if rclone.TLSEndpoint(name) != "" {
+				items = append(items, widget.NewFormItem("TLS:", widget.NewButtonWithIcon("Certificados...", theme.AccountIcon(), func() {
+					ShowTLSSettings(w, name)
+				})))
+			}
 
-			d := dialog.NewForm("Ajustes "+displayName, "Guardar", "Cancelar", items, func(ok bool) {
+			d = dialog.NewForm("Ajustes "+displayName, "Guardar", "Cancelar", items, func(ok bool) {
 				if ok {
+					// Partimos de lo guardado para no perder el resto de ajustes
 					currentOpts := settings.GetOptions(name)
-					settings.SetOptions(name, settings.RemoteOptions{
-						ReadOnly:     checkRead.Checked,
-						CacheSize:    entryCache.Text,
-						BwLimit:      entryBw.Text,
-						MountOnStart: currentOpts.MountOnStart,
-					})
+					bwChanged := currentOpts.BwLimit != entryBw.Text
+					// MEGAcmd solo entiende limites simples: no guardar uno que no pueda aplicar
+					if bwChanged && prov.ID() == "mega" {
+						if err := mega.ValidateSpeedLimit(entryBw.Text); err != nil {
+							dialog.ShowError(err, w)
+							return
+						}
+					}
+					currentOpts.ReadOnly = checkRead.Checked
+					currentOpts.CacheSize = entryCache.Text
+					currentOpts.BwLimit = entryBw.Text
+					if isDrive {
+						currentOpts.DriveExportFormats = strings.TrimSpace(entryExport.Text)
+						currentOpts.DriveImportFormats = strings.TrimSpace(entryImport.Text)
+						currentOpts.DriveSkipGdocs = checkSkipGdocs.Checked
+						currentOpts.DriveAcknowledgeAbuse = checkAbuse.Checked
+						useTrash := checkTrash.Checked
+						currentOpts.DriveUseTrash = &useTrash
+					}
+					settings.SetOptions(name, currentOpts)
+					go updateAutomount(w, name)
+
+					// MEGAcmd transfiere por su cuenta: comparte el limite de rclone
+					if bwChanged && prov.ID() == "mega" {
+						limit := currentOpts.BwLimit
+						go func() {
+							if err := mega.SetSpeedLimit(limit); err != nil {
+								fyne.Do(func() { dialog.ShowError(err, w) })
+							}
+						}()
+					}
 
 					if isMounted {
 						dialog.ShowInformation("Cambios", "Desmonta y monta la unidad para aplicar los limites.", w)
@@ -376,14 +562,18 @@ func ShowDashboard(w fyne.Window) {
 					}
 				}
 			}, w)
-			d.Resize(fyne.NewSize(400, 350))
+			if isDrive {
+				d.Resize(fyne.NewSize(520, 560))
+			} else {
+				d.Resize(fyne.NewSize(400, 350))
+			}
 			d.Show()
 		})
 
 		btnDelete := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
 			msg := "Eliminar configuracion de " + displayName + "?"
-			if isMega {
-				msg = "Cerrar sesion y eliminar Mega?"
+			if !provider.IsDefault(prov) {
+				msg = "Cerrar sesion y eliminar " + displayName + "?"
 			}
 			dialog.ShowConfirm("Borrar", msg, func(ok bool) {
 				if ok {
@@ -391,9 +581,8 @@ func ShowDashboard(w fyne.Window) {
 						if isMounted {
 							rclone.UnmountRemote(name)
 						}
-						if isMega {
-							mega.Logout()
-						}
+						prov.Teardown(name)
+						prov.Logout(name)
 						rclone.DeleteRemote(name)
 						fyne.Do(func() { ShowDashboard(w) })
 					}()
@@ -401,18 +590,128 @@ func ShowDashboard(w fyne.Window) {
 			}, w)
 		})
 
+		// Enlace con el remote base (carpetas cifradas)
+		linkInfo := widget.NewLabel("")
+		linkInfo.Hide()
+		if conf := dump[name]; conf["type"] == "crypt" {
+			linkInfo.SetText("Cifrado sobre " + conf["remote"])
+			linkInfo.Show()
+		} else if conf["type"] == "drive" && (conf["team_drive"] != "" || conf["shared_with_me"] == "true") {
+			linkInfo.SetText("Unidad compartida")
+			if conf["team_drive"] == "" {
+				linkInfo.SetText("Compartido conmigo")
+			}
+			linkInfo.Show()
+		}
+
+		// Estado propio del proveedor (p. ej. servidor de MEGAcmd)
+		provInfo := widget.NewLabel("")
+		provInfo.Wrapping = fyne.TextWrapWord
+		provInfo.Hide()
+
+		// Desglose del espacio de MEGA (asincrono: mega-df y mega-whoami tardan)
+		spaceInfo := widget.NewLabel("")
+		spaceInfo.Wrapping = fyne.TextWrapWord
+		spaceInfo.Hide()
+		loadSpace := func() {
+			go func() {
+				sp, err := mega.GetSpace()
+				if err != nil {
+					return
+				}
+				txt := fmt.Sprintf("Nube %s | Bandeja %s | Papelera %s | Versiones %s",
+					rclone.FormatBytes(sp.CloudDrive), rclone.FormatBytes(sp.Inbox),
+					rclone.FormatBytes(sp.Rubbish), rclone.FormatBytes(sp.Versions))
+				if level, err := mega.AccountLevel(); err == nil && level != "" {
+					txt += "\nCuenta: " + level
+				}
+				fyne.Do(func() {
+					spaceInfo.SetText(txt)
+					spaceInfo.Show()
+				})
+			}()
+		}
+
+		// El estado del proveedor puede tardar (Mega sondea MEGAcmd y la
+		// sesion): se pide aparte y rellena la tarjeta cuando llega
+		go func() {
+			provStatus := prov.Status(name)
+			fyne.Do(func() {
+				if provStatus.Detail != "" {
+					provInfo.SetText(provStatus.Detail)
+					provInfo.Show()
+				}
+				if provStatus.Text != "" && !token.NeedsReauth && !isMounted {
+					statusLabel.SetText(provStatus.Text)
+					statusIconW.SetResource(theme.InfoIcon())
+				}
+				if provStatus.Ready && !isMounted && len(members) == 0 {
+					loadQuota()
+				}
+				if provStatus.Ready && prov.ID() == "mega" {
+					loadSpace()
+				}
+			})
+		}()
+
+		tokenInfo := widget.NewLabel("")
+		tokenInfo.Wrapping = fyne.TextWrapWord
+		tokenInfo.Hide()
+		btnReconnect := widget.NewButtonWithIcon("Reconectar", theme.ViewRefreshIcon(), func() {
+			reconnectRemote(w, name, dump[name]["type"], isMounted)
+		})
+		btnReconnect.Hide()
+		if token.NeedsReauth {
+			tokenInfo.SetText(token.Reason)
+			tokenInfo.Show()
+			btnReconnect.Show()
+		}
+
+		btnMegaPanel := widget.NewButtonWithIcon("MEGAcmd", theme.ListIcon(), func() {
+			ShowMegaPanel(w, name)
+		})
+		if prov.ID() != "mega" {
+			btnMegaPanel.Hide()
+		}
+
+		// Salud de cada miembro dentro de la tarjeta del union/combine
+		membersBox := container.NewVBox()
+		for _, m := range members {
+			member := m
+			lbl := widget.NewLabel(member + ": comprobando...")
+			icon := widget.NewIcon(theme.MoreHorizontalIcon())
+			membersBox.Add(container.NewHBox(icon, lbl))
+			go func() {
+				err := rclone.CheckRemote(member)
+				fyne.Do(func() {
+					if err != nil {
+						icon.SetResource(theme.ErrorIcon())
+						lbl.SetText(member + ": " + err.Error())
+					} else {
+						icon.SetResource(theme.ConfirmIcon())
+						lbl.SetText(member + ": OK")
+					}
+				})
+			}()
+		}
+
 		// Ensamblaje de la tarjeta
 		cardContent := container.NewVBox(
 			container.NewHBox(
-				widget.NewIcon(statusIcon),
+				statusIconW,
 					  widget.NewLabelWithStyle(displayName, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 					  layout.NewSpacer(),
-					  widget.NewLabel(statusTxt),
+					  statusLabel,
 			),
+			linkInfo,
+			provInfo,
+			spaceInfo,
+			tokenInfo,
+			membersBox,
 			widget.NewSeparator(),
 						 container.NewBorder(nil, nil, widget.NewLabelWithData(quotaTxt), nil, widget.NewProgressBarWithData(quotaVal)),
 						 widget.NewSeparator(),
-						 container.NewHBox(btnMount, btnUnmount, btnOpen, layout.NewSpacer(), btnSettings, btnDelete),
+						 container.NewHBox(btnMount, btnUnmount, btnOpen, btnReconnect, btnMegaPanel, layout.NewSpacer(), btnSettings, btnDelete),
 		)
 
 		listContainer.Add(widget.NewCard("", "", cardContent))
@@ -434,6 +733,14 @@ func ShowDashboard(w fyne.Window) {
 	w.SetContent(content)
 }
 
+// updateAutomount reescribe la unidad de automontaje del remote, si la tiene,
+// tras cambiar ajustes que van en sus argumentos. Llamar fuera del hilo de UI.
+func updateAutomount(w fyne.Window, name string) {
+	if err := rclone.UpdateAutomount(name); err != nil {
+		fyne.Do(func() { dialog.ShowError(err, w) })
+	}
+}
+
 // ShowCloudSelection pantalla de seleccion
 func ShowCloudSelection(w fyne.Window) {
 	configState := binding.NewString()
@@ -454,101 +761,25 @@ func ShowCloudSelection(w fyne.Window) {
 				}
 			}, w)
 		} else if strings.HasPrefix(val, "ERROR:") {
-			fyne.Do(func() { dialog.ShowError(apiError(val[6:]), w) })
+			fyne.Do(func() {
+				ShowCloudSelection(w)
+				dialog.ShowError(apiError(val[6:]), w)
+			})
 		}
 	}))
 
-	configureMega := func() {
-		if !system.CheckMegaCmd() {
-			dialog.ShowConfirm("Instalar", "Se necesita MEGAcmd.\nInstalar automaticamente?", func(ok bool) {
-				if ok {
-					w.SetContent(container.NewVBox(layout.NewSpacer(), widget.NewLabel("Instalando MEGAcmd..."), widget.NewProgressBarInfinite(), layout.NewSpacer()))
-					go func() {
-						err := system.InstallMegaCmd()
-						fyne.Do(func() {
-							if err != nil {
-								ShowCloudSelection(w)
-								dialog.ShowError(err, w)
-							} else {
-								ShowCloudSelection(w)
-								dialog.ShowInformation("Instalado", "Vuelve a conectar.", w)
-							}
-						})
-					}()
-				}
-			}, w)
-			return
+	// finish lo usan los asistentes que viven en otros archivos
+	finish := func(name string, err error) {
+		if err != nil {
+			configState.Set("ERROR:" + err.Error())
+		} else {
+			configState.Set("DONE:" + name)
 		}
-
-		entryUser := widget.NewEntry()
-		entryUser.PlaceHolder = "Email"
-		entryPass := widget.NewPasswordEntry()
-		entryPass.PlaceHolder = "Contraseña"
-		entry2FA := widget.NewEntry()
-		entry2FA.PlaceHolder = "Codigo 2FA"
-
-		d := dialog.NewForm("Conectar Mega", "Login", "Cancelar", []*widget.FormItem{
-			widget.NewFormItem("Email:", entryUser),
-				    widget.NewFormItem("Pass:", entryPass),
-				    widget.NewFormItem("2FA:", entry2FA),
-		}, func(ok bool) {
-			if ok {
-				w.SetContent(container.NewVBox(layout.NewSpacer(), widget.NewLabel("Conectando..."), widget.NewProgressBarInfinite(), layout.NewSpacer()))
-				go func() {
-					err := mega.Login(strings.TrimSpace(entryUser.Text), strings.TrimSpace(entryPass.Text), strings.TrimSpace(entry2FA.Text))
-					if err != nil {
-						fyne.Do(func() {
-							ShowCloudSelection(w)
-							dialog.ShowError(fmt.Errorf("Login fallo: %v", err), w)
-						})
-						return
-					}
-					webdavURL, errUrl := mega.GetWebDAVURL()
-					if errUrl != nil {
-						fyne.Do(func() {
-							ShowCloudSelection(w)
-							dialog.ShowError(fmt.Errorf("Error puente: %v", errUrl), w)
-						})
-						return
-					}
-					opts := map[string]string{
-						"url":    webdavURL,
-						"vendor": "other",
-						"user":   strings.TrimSpace(entryUser.Text),
-				    "pass":   strings.TrimSpace(entryPass.Text),
-					}
-					rclone.CreateConfigWithOpts("Mega", "webdav", opts)
-
-					fyne.Do(func() {
-						dialog.ShowInformation("Conectado", "Mega configurado.", w)
-						ShowDashboard(w)
-					})
-				}()
-			}
-		}, w)
-		d.Resize(fyne.NewSize(450, 300))
-		d.Show()
-	}
-
-	configureOAuth := func(name, provider string) {
-		input := widget.NewEntry()
-		input.PlaceHolder = "Nombre"
-		dialog.ShowCustomConfirm("Configurar "+name, "Ok", "Cancel", input, func(ok bool) {
-			if ok && input.Text != "" {
-				w.SetContent(widget.NewLabel("Autorizando..."))
-				go func() {
-					if err := rclone.CreateConfig(input.Text, provider); err != nil {
-						configState.Set("ERROR:" + err.Error())
-					} else {
-						configState.Set("DONE:" + input.Text)
-					}
-				}()
-			}
-		}, w)
 	}
 
-	configureManual := func(title, provider string) {
+	configureManual := func(title, backend string) {
 		entryName := widget.NewEntry()
+		entryName.Validator = rclone.NewRemoteNameValidator()
 		entryURL := widget.NewEntry()
 		entryURL.PlaceHolder = "https://..."
 		entryUser := widget.NewEntry()
@@ -566,11 +797,8 @@ func ShowCloudSelection(w fyne.Window) {
 					"pass":   entryPass.Text,
 					"vendor": "other",
 				}
-				if provider == "nextcloud" {
-					opts["vendor"] = "nextcloud"
-				}
 				go func() {
-					if err := rclone.CreateConfigWithOpts(entryName.Text, provider, opts); err != nil {
+					if err := rclone.CreateConfigWithOpts(entryName.Text, backend, opts); err != nil {
 						configState.Set("ERROR:" + err.Error())
 					} else {
 						configState.Set("DONE:" + entryName.Text)
@@ -582,55 +810,25 @@ func ShowCloudSelection(w fyne.Window) {
 		d.Show()
 	}
 
-	configureS3 := func() {
-		entryName := widget.NewEntry()
-		entryProvider := widget.NewSelect([]string{"AWS", "Minio", "Wasabi", "Other"}, nil)
-		entryAccess := widget.NewEntry()
-		entrySecret := widget.NewPasswordEntry()
-		entryEndpoint := widget.NewEntry()
-		d := dialog.NewForm("Configurar S3", "Ok", "Cancel", []*widget.FormItem{
-			widget.NewFormItem("Nombre:", entryName),
-				    widget.NewFormItem("Prov:", entryProvider),
-				    widget.NewFormItem("Access:", entryAccess),
-				    widget.NewFormItem("Secret:", entrySecret),
-				    widget.NewFormItem("Endpoint:", entryEndpoint),
-		}, func(ok bool) {
-			if ok {
-				opts := map[string]string{
-					"provider":          entryProvider.Selected,
-					"env_auth":          "false",
-					"access_key_id":     entryAccess.Text,
-					"secret_access_key": entrySecret.Text,
-				}
-				if entryEndpoint.Text != "" {
-					opts["endpoint"] = entryEndpoint.Text
-				}
-				go func() {
-					if err := rclone.CreateConfigWithOpts(entryName.Text, "s3", opts); err != nil {
-						configState.Set("ERROR:" + err.Error())
-					} else {
-						configState.Set("DONE:" + entryName.Text)
-					}
-				}()
-			}
-		}, w)
-		d.Resize(fyne.NewSize(500, 400))
-		d.Show()
-	}
-
 	cloudList := container.NewVBox(
 		widget.NewLabelWithStyle("Populares", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
-				       widget.NewButtonWithIcon("Mega.nz (Oficial)", theme.UploadIcon(), configureMega),
-				       widget.NewButtonWithIcon("Google Drive", theme.StorageIcon(), func() { configureOAuth("Google Drive", "drive") }),
-				       widget.NewButtonWithIcon("Dropbox", theme.ContentAddIcon(), func() { configureOAuth("Dropbox", "dropbox") }),
-				       widget.NewButtonWithIcon("OneDrive", theme.FolderIcon(), func() { configureOAuth("OneDrive", "onedrive") }),
+				       widget.NewButtonWithIcon("Mega.nz", theme.UploadIcon(), func() { ShowMegaWizard(w, finish) }),
+				       widget.NewButtonWithIcon("Google Drive", theme.StorageIcon(), func() { ShowOAuthWizard(w, "Google Drive", "drive", finish) }),
+				       widget.NewButtonWithIcon("Dropbox", theme.ContentAddIcon(), func() { ShowOAuthWizard(w, "Dropbox", "dropbox", finish) }),
+				       widget.NewButtonWithIcon("OneDrive", theme.FolderIcon(), func() { ShowOAuthWizard(w, "OneDrive", "onedrive", finish) }),
 				       widget.NewSeparator(),
 				       widget.NewLabelWithStyle("Avanzado", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
-				       widget.NewButtonWithIcon("pCloud", theme.StorageIcon(), func() { configureOAuth("pCloud", "pcloud") }),
-				       widget.NewButtonWithIcon("Box", theme.ContentCopyIcon(), func() { configureOAuth("Box", "box") }),
-				       widget.NewButtonWithIcon("Nextcloud", theme.ComputerIcon(), func() { configureManual("Nextcloud", "webdav") }),
+				       widget.NewButtonWithIcon("pCloud", theme.StorageIcon(), func() { ShowOAuthWizard(w, "pCloud", "pcloud", finish) }),
+				       widget.NewButtonWithIcon("Box", theme.ContentCopyIcon(), func() { ShowOAuthWizard(w, "Box", "box", finish) }),
+				       widget.NewButtonWithIcon("Nextcloud", theme.ComputerIcon(), func() { ShowNextcloudWizard(w, finish) }),
 				       widget.NewButtonWithIcon("WebDAV", theme.FileIcon(), func() { configureManual("WebDAV", "webdav") }),
-				       widget.NewButtonWithIcon("S3 / AWS", theme.SettingsIcon(), configureS3),
+				       widget.NewButtonWithIcon("S3 / AWS", theme.SettingsIcon(), func() { ShowS3Wizard(w, finish) }),
+				       widget.NewButtonWithIcon("Cuenta de servicio Google", theme.AccountIcon(), func() { ShowServiceAccountWizard(w, finish) }),
+				       widget.NewButtonWithIcon("SFTP / SSH", theme.ComputerIcon(), func() { ShowSFTPWizard(w, finish) }),
+				       widget.NewButtonWithIcon("SMB / NAS Windows", theme.StorageIcon(), func() { ShowSMBWizard(w, finish) }),
+				       widget.NewButtonWithIcon("FTP / FTPS", theme.DownloadIcon(), func() { ShowFTPWizard(w, finish) }),
+				       widget.NewButtonWithIcon("Cifrar una carpeta", theme.VisibilityOffIcon(), func() { ShowCryptWizard(w) }),
+				       widget.NewButtonWithIcon("Unir cuentas", theme.ContentPasteIcon(), func() { ShowUnionWizard(w) }),
 				       widget.NewSeparator(),
 				       widget.NewButtonWithIcon("Volver", theme.CancelIcon(), func() { ShowDashboard(w) }),
 	)
@@ -638,7 +836,7 @@ func ShowCloudSelection(w fyne.Window) {
 	w.SetContent(container.NewBorder(nil, nil, nil, nil, container.NewPadded(container.NewVScroll(cloudList))))
 }
 
-func apiError(msg string) error { return fmt.Errorf(msg) }
+func apiError(msg string) error { return errors.New(msg) }
 
 type myTheme struct{}
 
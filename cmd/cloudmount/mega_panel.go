@@ -0,0 +1,248 @@
+package main
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/mega"
+	"github.com/anabasasoft/cloudmount-wizard/internal/rclone"
+	"github.com/anabasasoft/cloudmount-wizard/internal/settings"
+)
+
+// ShowMegaPanel gestiona lo que MEGAcmd ofrece además del montaje:
+// sincronizaciones, enlaces públicos, transferencias y límite de velocidad
+func ShowMegaPanel(w fyne.Window, name string) {
+	tabs := container.NewAppTabs(
+		container.NewTabItemWithIcon("Sincronizar", theme.ViewRefreshIcon(), megaSyncTab(w)),
+		container.NewTabItemWithIcon("Enlaces", theme.MailForwardIcon(), megaExportTab(w)),
+		container.NewTabItemWithIcon("Transferencias", theme.UploadIcon(), megaTransfersTab(w)),
+		container.NewTabItemWithIcon("Velocidad", theme.SettingsIcon(), megaSpeedTab(w, name)),
+	)
+	top := container.NewHBox(
+		widget.NewLabelWithStyle("MEGAcmd: "+name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		layout.NewSpacer(),
+		widget.NewButtonWithIcon("Volver", theme.NavigateBackIcon(), func() { ShowDashboard(w) }),
+	)
+	w.SetContent(container.NewBorder(top, nil, nil, nil, tabs))
+}
+
+// megaList rellena box en segundo plano con las filas que devuelve load
+func megaList(box *fyne.Container, load func() ([]fyne.CanvasObject, error)) {
+	box.Objects = []fyne.CanvasObject{widget.NewLabel("Cargando...")}
+	box.Refresh()
+	go func() {
+		rows, err := load()
+		fyne.Do(func() {
+			box.Objects = nil
+			if err != nil {
+				box.Add(widget.NewLabel(err.Error()))
+			} else if len(rows) == 0 {
+				box.Add(widget.NewLabel("Nada por aqui."))
+			}
+			for _, r := range rows {
+				box.Add(r)
+			}
+			box.Refresh()
+		})
+	}()
+}
+
+// megaAction ejecuta una acción de MEGAcmd y al terminar recarga la lista
+func megaAction(w fyne.Window, action func() error, reload func()) {
+	go func() {
+		err := action()
+		fyne.Do(func() {
+			if err != nil {
+				dialog.ShowError(err, w)
+			}
+			reload()
+		})
+	}()
+}
+
+func megaSyncTab(w fyne.Window) fyne.CanvasObject {
+	box := container.NewVBox()
+	var reload func()
+	reload = func() {
+		megaList(box, func() ([]fyne.CanvasObject, error) {
+			pairs, err := mega.ListSyncs()
+			if err != nil {
+				return nil, err
+			}
+			var rows []fyne.CanvasObject
+			for _, p := range pairs {
+				pair := p
+				state := pair.RunState + " / " + pair.Status
+				if pair.Error != "" && !strings.EqualFold(pair.Error, "NO") {
+					state += " / " + pair.Error
+				}
+				var btnToggle *widget.Button
+				if strings.EqualFold(pair.RunState, "Running") {
+					btnToggle = widget.NewButtonWithIcon("", theme.MediaPauseIcon(), func() {
+						megaAction(w, func() error { return mega.PauseSync(pair.ID) }, reload)
+					})
+				} else {
+					btnToggle = widget.NewButtonWithIcon("", theme.MediaPlayIcon(), func() {
+						megaAction(w, func() error { return mega.ResumeSync(pair.ID) }, reload)
+					})
+				}
+				btnDelete := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+					dialog.ShowConfirm("Sincronizar", "Dejar de sincronizar "+pair.LocalPath+"?\nLos archivos no se borran.", func(ok bool) {
+						if ok {
+							megaAction(w, func() error { return mega.RemoveSync(pair.ID) }, reload)
+						}
+					}, w)
+				})
+				rows = append(rows, container.NewBorder(nil, nil, nil, container.NewHBox(btnToggle, btnDelete),
+					widget.NewLabel(pair.LocalPath+"  <->  "+pair.RemotePath+"\n"+state)))
+			}
+			return rows, nil
+		})
+	}
+
+	entryLocal := widget.NewEntry()
+	entryLocal.PlaceHolder = "Carpeta local"
+	btnPick := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err == nil && uri != nil {
+				entryLocal.SetText(uri.Path())
+			}
+		}, w)
+	})
+	entryRemote := widget.NewEntry()
+	entryRemote.PlaceHolder = "/Carpeta en MEGA"
+	btnAdd := widget.NewButtonWithIcon("Anadir", theme.ContentAddIcon(), func() {
+		local, remote := strings.TrimSpace(entryLocal.Text), strings.TrimSpace(entryRemote.Text)
+		if local == "" || remote == "" {
+			return
+		}
+		megaAction(w, func() error { return mega.AddSync(local, remote) }, reload)
+	})
+
+	form := container.NewVBox(
+		container.NewBorder(nil, nil, nil, btnPick, entryLocal),
+		container.NewBorder(nil, nil, nil, btnAdd, entryRemote),
+		widget.NewSeparator(),
+	)
+	reload()
+	return container.NewBorder(form, widget.NewButtonWithIcon("Actualizar", theme.ViewRefreshIcon(), reload), nil, nil, container.NewVScroll(box))
+}
+
+func megaExportTab(w fyne.Window) fyne.CanvasObject {
+	box := container.NewVBox()
+	var reload func()
+	reload = func() {
+		megaList(box, func() ([]fyne.CanvasObject, error) {
+			exports, err := mega.ListExports()
+			if err != nil {
+				return nil, err
+			}
+			var rows []fyne.CanvasObject
+			for _, e := range exports {
+				export := e
+				btnCopy := widget.NewButtonWithIcon("", theme.ContentCopyIcon(), func() {
+					fyne.CurrentApp().Clipboard().SetContent(export.Link)
+				})
+				btnDelete := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+					megaAction(w, func() error { return mega.RemoveExport(export.Path) }, reload)
+				})
+				rows = append(rows, container.NewBorder(nil, nil, nil, container.NewHBox(btnCopy, btnDelete),
+					widget.NewLabel(export.Path+"\n"+export.Link)))
+			}
+			return rows, nil
+		})
+	}
+
+	entryPath := widget.NewEntry()
+	entryPath.PlaceHolder = "/Ruta en MEGA"
+	btnExport := widget.NewButtonWithIcon("Crear enlace", theme.MailForwardIcon(), func() {
+		path := strings.TrimSpace(entryPath.Text)
+		if path == "" {
+			return
+		}
+		go func() {
+			link, err := mega.CreateExport(path)
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				fyne.CurrentApp().Clipboard().SetContent(link)
+				dialog.ShowInformation("Enlace copiado", link, w)
+				reload()
+			})
+		}()
+	})
+
+	reload()
+	return container.NewBorder(
+		container.NewVBox(container.NewBorder(nil, nil, nil, btnExport, entryPath), widget.NewSeparator()),
+		widget.NewButtonWithIcon("Actualizar", theme.ViewRefreshIcon(), reload),
+		nil, nil, container.NewVScroll(box))
+}
+
+func megaTransfersTab(w fyne.Window) fyne.CanvasObject {
+	box := container.NewVBox()
+	var reload func()
+	reload = func() {
+		megaList(box, func() ([]fyne.CanvasObject, error) {
+			transfers, err := mega.ListTransfers()
+			if err != nil {
+				return nil, err
+			}
+			var rows []fyne.CanvasObject
+			for _, t := range transfers {
+				transfer := t
+				btnCancel := widget.NewButtonWithIcon("", theme.CancelIcon(), func() {
+					megaAction(w, func() error { return mega.CancelTransfer(transfer.Tag) }, reload)
+				})
+				rows = append(rows, container.NewBorder(nil, nil, nil, btnCancel,
+					widget.NewLabel(transfer.Type+" "+transfer.Source+" -> "+transfer.Destination+"\n"+transfer.Progress+" "+transfer.State)))
+			}
+			return rows, nil
+		})
+	}
+	reload()
+	return container.NewBorder(nil, widget.NewButtonWithIcon("Actualizar", theme.ViewRefreshIcon(), reload), nil, nil, container.NewVScroll(box))
+}
+
+// megaSpeedTab comparte el límite con el ajuste "Ancho Banda" de la unidad
+func megaSpeedTab(w fyne.Window, name string) fyne.CanvasObject {
+	entry := widget.NewEntry()
+	entry.SetText(settings.GetOptions(name).BwLimit)
+	entry.PlaceHolder = "Ej: 2M o 1M:4M (subida:bajada). Vacio = sin limite"
+	btnApply := widget.NewButtonWithIcon("Aplicar", theme.ConfirmIcon(), func() {
+		limit := strings.TrimSpace(entry.Text)
+		go func() {
+			err := mega.SetSpeedLimit(limit)
+			if err == nil {
+				opts := settings.GetOptions(name)
+				opts.BwLimit = limit
+				err = settings.SetOptions(name, opts)
+			}
+			if err == nil {
+				err = rclone.UpdateAutomount(name)
+			}
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, w)
+				} else {
+					dialog.ShowInformation("Velocidad", "Limite aplicado en MEGAcmd y en el montaje.", w)
+				}
+			})
+		}()
+	})
+	hint := widget.NewLabel("El mismo limite se usa para las transferencias de MEGAcmd y para rclone al montar.")
+	hint.Wrapping = fyne.TextWrapWord
+	return container.NewVBox(
+		widget.NewForm(widget.NewFormItem("Limite:", entry)),
+		hint,
+		container.NewHBox(layout.NewSpacer(), btnApply),
+	)
+}
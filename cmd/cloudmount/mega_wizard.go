@@ -0,0 +1,386 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/mega"
+	"github.com/anabasasoft/cloudmount-wizard/internal/provider"
+	"github.com/anabasasoft/cloudmount-wizard/internal/rclone"
+	"github.com/anabasasoft/cloudmount-wizard/internal/settings"
+	"github.com/anabasasoft/cloudmount-wizard/internal/system"
+)
+
+const (
+	megaNative = "rclone (sin instalar nada)"
+	megaCmd    = "MEGAcmd (cliente oficial)"
+)
+
+// ShowMegaWizard deja elegir entre el backend mega de rclone y el puente
+// WebDAV de MEGAcmd
+func ShowMegaWizard(w fyne.Window, finish func(name string, err error)) {
+	choice := widget.NewRadioGroup([]string{megaNative, megaCmd}, nil)
+	choice.SetSelected(megaNative)
+	hint := widget.NewLabel("rclone habla directamente con Mega. MEGAcmd es el programa oficial y monta a traves de su servidor WebDAV local.")
+	hint.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewCustomConfirm("Conectar Mega", "Siguiente", "Cancelar", container.NewVBox(hint, choice), func(ok bool) {
+		if !ok {
+			return
+		}
+		if choice.Selected == megaCmd {
+			showMegaCmdLogin(w)
+		} else {
+			showMegaNativeLogin(w, finish)
+		}
+	}, w)
+	d.Resize(fyne.NewSize(450, 250))
+	d.Show()
+}
+
+// showMegaNativeLogin crea un remote del backend mega de rclone
+func showMegaNativeLogin(w fyne.Window, finish func(string, error)) {
+	entryName := widget.NewEntry()
+	entryName.SetText("Mega")
+	entryName.Validator = rclone.NewRemoteNameValidator()
+	entryUser := widget.NewEntry()
+	entryUser.PlaceHolder = "Email"
+	entryPass := widget.NewPasswordEntry()
+	entryPass.PlaceHolder = "Contraseña"
+	entry2FA := widget.NewEntry()
+	entry2FA.PlaceHolder = "Codigo 2FA (si lo tienes activado)"
+
+	d := dialog.NewForm("Conectar Mega (rclone)", "Login", "Cancelar", []*widget.FormItem{
+		widget.NewFormItem("Nombre:", entryName),
+		widget.NewFormItem("Email:", entryUser),
+		widget.NewFormItem("Pass:", entryPass),
+		widget.NewFormItem("2FA:", entry2FA),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		name := entryName.Text
+		user := strings.TrimSpace(entryUser.Text)
+		pass := entryPass.Text
+		code := strings.TrimSpace(entry2FA.Text)
+		w.SetContent(container.NewVBox(layout.NewSpacer(), widget.NewLabel("Conectando..."), widget.NewProgressBarInfinite(), layout.NewSpacer()))
+		go func() {
+			err := rclone.CreateMega(name, user, pass, code)
+			if err == nil {
+				// Guardar el proveedor: un remote llamado "Mega" se tomaria
+				// por un puente antiguo de MEGAcmd
+				opts := settings.GetOptions(name)
+				opts.Provider = provider.Rclone{}.ID()
+				err = settings.SetOptions(name, opts)
+			}
+			finish(name, err)
+		}()
+	}, w)
+	d.Resize(fyne.NewSize(450, 320))
+	d.Show()
+}
+
+// showMegaCmdLogin inicia sesión en MEGAcmd y crea el remote del puente
+func showMegaCmdLogin(w fyne.Window) {
+	if !system.CheckMegaCmd() {
+		dialog.ShowConfirm("Instalar", "Se necesita MEGAcmd.\nInstalar automaticamente?", func(ok bool) {
+			if ok {
+				w.SetContent(container.NewVBox(layout.NewSpacer(), widget.NewLabel("Instalando MEGAcmd..."), widget.NewProgressBarInfinite(), layout.NewSpacer()))
+				go func() {
+					err := system.InstallMegaCmd()
+					fyne.Do(func() {
+						if err != nil {
+							ShowCloudSelection(w)
+							dialog.ShowError(err, w)
+						} else {
+							ShowCloudSelection(w)
+							dialog.ShowInformation("Instalado", "Vuelve a conectar.", w)
+						}
+					})
+				}()
+			}
+		}, w)
+		return
+	}
+
+	// Si MEGAcmd ya tiene sesión la ofrecemos antes de pedir credenciales
+	w.SetContent(container.NewVBox(layout.NewSpacer(), widget.NewLabel("Comprobando sesion de MEGAcmd..."), widget.NewProgressBarInfinite(), layout.NewSpacer()))
+	go func() {
+		mega.EnsureDaemon()
+		account := mega.CurrentAccount()
+		fyne.Do(func() {
+			ShowCloudSelection(w)
+			if account == "" {
+				showMegaCmdForm(w)
+				return
+			}
+			msg := widget.NewLabel("MEGAcmd ya tiene sesion iniciada con\n" + account)
+			dialog.ShowCustomConfirm("Conectar Mega", "Usar esta cuenta", "Otra cuenta", msg, func(reuse bool) {
+				if reuse {
+					connectMegaCmd(w, map[string]string{"user": account})
+				} else {
+					showMegaCmdForm(w)
+				}
+			}, w)
+		})
+	}()
+}
+
+// showMegaCmdForm pide las credenciales de MEGA. El 2FA se pide después,
+// solo si la cuenta lo necesita.
+func showMegaCmdForm(w fyne.Window) {
+	entryUser := widget.NewEntry()
+	entryUser.PlaceHolder = "Email"
+	entryPass := widget.NewPasswordEntry()
+	entryPass.PlaceHolder = "Contraseña"
+
+	d := dialog.NewForm("Conectar Mega", "Login", "Cancelar", []*widget.FormItem{
+		widget.NewFormItem("Email:", entryUser),
+		widget.NewFormItem("Pass:", entryPass),
+	}, func(ok bool) {
+		if ok {
+			connectMegaCmd(w, map[string]string{
+				"user": strings.TrimSpace(entryUser.Text),
+				"pass": strings.TrimSpace(entryPass.Text),
+			})
+		}
+	}, w)
+	d.Resize(fyne.NewSize(450, 250))
+	d.Show()
+}
+
+// connectMegaCmd inicia sesión en MEGAcmd y, si MEGA pide el código de
+// verificación en dos pasos, lo pregunta y reintenta. Después se eligen
+// las carpetas a montar.
+func connectMegaCmd(w fyne.Window, params map[string]string) {
+	w.SetContent(container.NewVBox(layout.NewSpacer(), widget.NewLabel("Conectando..."), widget.NewProgressBarInfinite(), layout.NewSpacer()))
+	go func() {
+		err := mega.Login(params["user"], params["pass"], params["2fa"])
+		fyne.Do(func() {
+			switch {
+			case errors.Is(err, mega.ErrNeeds2FA):
+				ShowCloudSelection(w)
+				entry2FA := widget.NewEntry()
+				entry2FA.PlaceHolder = "123456"
+				dialog.ShowForm("Verificacion en dos pasos", "Continuar", "Cancelar", []*widget.FormItem{
+					widget.NewFormItem("Codigo 2FA:", entry2FA),
+				}, func(ok bool) {
+					if ok && strings.TrimSpace(entry2FA.Text) != "" {
+						params["2fa"] = strings.TrimSpace(entry2FA.Text)
+						connectMegaCmd(w, params)
+					}
+				}, w)
+			case err != nil:
+				ShowCloudSelection(w)
+				dialog.ShowError(fmt.Errorf("Login fallo: %v", err), w)
+			default:
+				showMegaFolders(w, params["user"], params["pass"], func() { ShowCloudSelection(w) })
+			}
+		})
+	}()
+}
+
+// megaShare es una carpeta de MEGA elegida para montarse como unidad
+type megaShare struct {
+	check *widget.Check
+	path  *widget.Entry
+	name  *widget.Entry
+}
+
+// showMegaFolders deja elegir qué carpetas de MEGA se sirven por WebDAV;
+// cada una se monta como una unidad propia. La sesión ya debe estar iniciada.
+func showMegaFolders(w fyne.Window, user, pass string, cancel func()) {
+	w.SetContent(container.NewVBox(layout.NewSpacer(), widget.NewLabel("Leyendo carpetas de MEGA..."), widget.NewProgressBarInfinite(), layout.NewSpacer()))
+	go func() {
+		folders, err := mega.ListFolders("/")
+		fyne.Do(func() {
+			if err != nil {
+				cancel()
+				dialog.ShowError(err, w)
+				return
+			}
+			showMegaFolderPicker(w, user, pass, folders, cancel)
+		})
+	}()
+}
+
+func showMegaFolderPicker(w fyne.Window, user, pass string, folders []string, cancel func()) {
+	var shares []megaShare
+	validateName := rclone.NewRemoteNameValidator()
+	addRow := func(label, dir, name string) megaShare {
+		sh := megaShare{check: widget.NewCheck(label, nil), path: widget.NewEntry(), name: widget.NewEntry()}
+		sh.path.SetText(dir)
+		sh.name.SetText(name)
+		sh.name.Validator = validateName
+		shares = append(shares, sh)
+		return sh
+	}
+
+	list := container.NewVBox()
+	root := addRow("Toda la cuenta", "", "Mega")
+	root.check.SetChecked(validateName("Mega") == nil)
+	list.Add(container.NewGridWithColumns(2, root.check, root.name))
+	for _, f := range folders {
+		sh := addRow(f, "/"+f, "Mega - "+f)
+		list.Add(container.NewGridWithColumns(2, sh.check, sh.name))
+	}
+	other := addRow("Otra carpeta:", "", "")
+	other.path.PlaceHolder = "/Carpeta/Subcarpeta"
+	other.name.PlaceHolder = "Nombre de la unidad"
+	other.path.OnChanged = func(p string) {
+		other.check.SetChecked(strings.TrimSpace(p) != "")
+		if base := path.Base(strings.TrimSpace(p)); base != "/" && base != "." {
+			other.name.SetText("Mega - " + base)
+		}
+	}
+	list.Add(widget.NewSeparator())
+	list.Add(container.NewGridWithColumns(2, container.NewBorder(nil, nil, other.check, nil, other.path), other.name))
+
+	btnSave := widget.NewButtonWithIcon("Guardar", theme.ConfirmIcon(), func() {
+		var chosen []megaShare
+		seen := map[string]bool{}
+		for _, sh := range shares {
+			if !sh.check.Checked {
+				continue
+			}
+			if err := sh.name.Validate(); err != nil {
+				dialog.ShowError(fmt.Errorf("%s: %v", sh.check.Text, err), w)
+				return
+			}
+			if seen[sh.name.Text] {
+				dialog.ShowError(fmt.Errorf("el nombre '%s' esta repetido", sh.name.Text), w)
+				return
+			}
+			seen[sh.name.Text] = true
+			chosen = append(chosen, sh)
+		}
+		if len(chosen) == 0 {
+			dialog.ShowInformation("Mega", "Elige al menos una carpeta.", w)
+			return
+		}
+		w.SetContent(container.NewVBox(layout.NewSpacer(), widget.NewLabel("Creando unidades..."), widget.NewProgressBarInfinite(), layout.NewSpacer()))
+		go func() {
+			var err error
+			for _, sh := range chosen {
+				err = provider.Get("mega").Configure(sh.name.Text, map[string]string{
+					"user": user,
+					"pass": pass,
+					"path": strings.TrimSpace(sh.path.Text),
+				})
+				if err != nil {
+					err = fmt.Errorf("%s: %v", sh.name.Text, err)
+					break
+				}
+			}
+			fyne.Do(func() {
+				ShowDashboard(w)
+				if err != nil {
+					dialog.ShowError(err, w)
+				} else {
+					dialog.ShowInformation("Conectado", "Mega configurado con "+user+".", w)
+				}
+			})
+		}()
+	})
+	btnSave.Importance = widget.HighImportance
+
+	hint := widget.NewLabel("Cada carpeta marcada se sirve por separado y aparece como una unidad propia.")
+	hint.Wrapping = fyne.TextWrapWord
+	top := container.NewVBox(
+		widget.NewLabelWithStyle("Carpetas de MEGA ("+user+")", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		hint,
+		widget.NewSeparator(),
+	)
+	bottom := container.NewHBox(
+		widget.NewButtonWithIcon("Cancelar", theme.CancelIcon(), cancel),
+		layout.NewSpacer(),
+		btnSave,
+	)
+	w.SetContent(container.NewBorder(top, bottom, nil, nil, container.NewVScroll(list)))
+}
+
+// showMegaMigration pasa un remote del puente de MEGAcmd al backend mega
+// de rclone conservando nombre, carpeta de montaje y ajustes
+func showMegaMigration(w fyne.Window, name string) {
+	// Si el puente reutilizo la sesion de MEGAcmd no guardo la contraseña
+	go func() {
+		conf, err := rclone.GetRemoteConfig(name)
+		fyne.Do(func() {
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			showMegaMigrationForm(w, name, conf["pass"] == "")
+		})
+	}()
+}
+
+func showMegaMigrationForm(w fyne.Window, name string, askPass bool) {
+	entryPass := widget.NewPasswordEntry()
+	entry2FA := widget.NewEntry()
+	entry2FA.PlaceHolder = "Codigo 2FA (si lo tienes activado)"
+	hint := widget.NewLabel("La unidad dejara de depender de MEGAcmd. Se desmontara durante el cambio.")
+	hint.Wrapping = fyne.TextWrapWord
+
+	items := []*widget.FormItem{widget.NewFormItem("", hint)}
+	if askPass {
+		entryPass.Validator = func(s string) error {
+			if s == "" {
+				return fmt.Errorf("falta la contraseña")
+			}
+			return nil
+		}
+		items = append(items, widget.NewFormItem("Pass:", entryPass))
+	}
+	items = append(items, widget.NewFormItem("2FA:", entry2FA))
+
+	d := dialog.NewForm("Usar el backend de rclone", "Migrar", "Cancelar", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		pass := entryPass.Text
+		code := strings.TrimSpace(entry2FA.Text)
+		w.SetContent(container.NewVBox(layout.NewSpacer(), widget.NewLabel("Migrando "+name+"..."), widget.NewProgressBarInfinite(), layout.NewSpacer()))
+		go func() {
+			wasMounted := rclone.IsMounted(rclone.GetMountPath(name))
+			rclone.UnmountRemote(name)
+			err := rclone.MigrateMegaBridge(name, pass, code)
+			if err == nil {
+				// El puente ya no hace falta
+				provider.For(name).Teardown(name)
+				opts := settings.GetOptions(name)
+				opts.Provider = provider.Rclone{}.ID()
+				// La carpeta que servia el puente pasa a ser la subcarpeta del montaje
+				if opts.MegaPath != "" {
+					opts.RemotePath = strings.TrimPrefix(opts.MegaPath, "/")
+					opts.MegaPath = ""
+				}
+				err = settings.SetOptions(name, opts)
+			} else if wasMounted {
+				// La configuracion anterior sigue intacta: dejamos la unidad como estaba
+				if perr := provider.For(name).PrepareMount(name); perr == nil || errors.As(perr, new(provider.Warning)) {
+					rclone.MountRemote(name)
+				}
+			}
+			fyne.Do(func() {
+				ShowDashboard(w)
+				if err != nil {
+					dialog.ShowError(err, w)
+				} else {
+					dialog.ShowInformation("Mega", "'"+name+"' usa ahora el backend de rclone.", w)
+				}
+			})
+		}()
+	}, w)
+	d.Resize(fyne.NewSize(450, 300))
+	d.Show()
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/nextcloud"
+	"github.com/anabasasoft/cloudmount-wizard/internal/rclone"
+)
+
+// ShowNextcloudWizard conecta con Nextcloud (Login Flow v2) u ownCloud
+// (contraseña de aplicación) pidiendo solo la dirección del servidor
+func ShowNextcloudWizard(w fyne.Window, finish func(name string, err error)) {
+	entryName := widget.NewEntry()
+	entryName.Validator = rclone.NewRemoteNameValidator()
+	entryServer := widget.NewEntry()
+	entryServer.PlaceHolder = "nube.ejemplo.com"
+	entryServer.Validator = func(s string) error {
+		_, err := nextcloud.NormalizeServer(s)
+		return err
+	}
+	selVendor := widget.NewSelect([]string{"Nextcloud", "ownCloud"}, nil)
+	selVendor.SetSelectedIndex(0)
+
+	d := dialog.NewForm("Nextcloud / ownCloud", "Conectar", "Cancelar", []*widget.FormItem{
+		widget.NewFormItem("Nombre:", entryName),
+		widget.NewFormItem("Servidor:", entryServer),
+		widget.NewFormItem("Tipo:", selVendor),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		name := entryName.Text
+		server, _ := nextcloud.NormalizeServer(entryServer.Text)
+		if selVendor.Selected == "ownCloud" {
+			askAppPassword(w, name, server, "owncloud", finish)
+			return
+		}
+		runLoginFlow(w, name, server, finish)
+	}, w)
+	d.Resize(fyne.NewSize(480, 280))
+	d.Show()
+}
+
+// runLoginFlow abre el navegador para que el usuario apruebe el acceso y
+// espera la contraseña de aplicación que genera Nextcloud
+func runLoginFlow(w fyne.Window, name, server string, finish func(string, error)) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Minute)
+
+	status := widget.NewLabel("Contactando con " + server + "...")
+	link := widget.NewHyperlink("", nil)
+	link.Hide()
+	btnCancel := widget.NewButtonWithIcon("Cancelar", theme.CancelIcon(), func() {
+		cancel()
+		ShowCloudSelection(w)
+	})
+	w.SetContent(container.NewVBox(
+		layout.NewSpacer(),
+		widget.NewLabelWithStyle("Iniciar sesion en Nextcloud", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		status,
+		link,
+		widget.NewProgressBarInfinite(),
+		container.NewCenter(btnCancel),
+		layout.NewSpacer(),
+	))
+
+	go func() {
+		defer cancel()
+		flow, err := nextcloud.Start(ctx, server)
+		if ctx.Err() == context.Canceled {
+			return // Cancelado por el usuario
+		}
+		if errors.Is(err, nextcloud.ErrNotSupported) {
+			// Servidor antiguo: contraseña de aplicación a mano
+			fyne.Do(func() { askAppPassword(w, name, server, "nextcloud", finish) })
+			return
+		}
+		if err != nil {
+			finish(name, err)
+			return
+		}
+
+		loginURL, _ := url.Parse(flow.LoginURL)
+		fyne.Do(func() {
+			status.SetText("Autoriza el acceso en el navegador. Si no se abre, usa este enlace:")
+			link.SetText(flow.LoginURL)
+			link.SetURL(loginURL)
+			link.Show()
+			fyne.CurrentApp().OpenURL(loginURL)
+		})
+
+		creds, err := flow.Wait(ctx, 2*time.Second)
+		if ctx.Err() == context.Canceled {
+			return // Cancelado por el usuario
+		}
+		if err != nil {
+			finish(name, err)
+			return
+		}
+		fyne.Do(func() { status.SetText("Acceso concedido a " + creds.LoginName + ". Guardando...") })
+		// El servidor indica su dirección canónica (puede diferir de la escrita)
+		if canonical, err := nextcloud.NormalizeServer(creds.Server); err == nil {
+			server = canonical
+		}
+		finish(name, createNextcloudRemote(name, server, "nextcloud", creds.LoginName, creds.AppPassword))
+	}()
+}
+
+// askAppPassword pide usuario y contraseña de aplicación (ownCloud o
+// Nextcloud sin Login Flow v2)
+func askAppPassword(w fyne.Window, name, server, vendor string, finish func(string, error)) {
+	ShowCloudSelection(w)
+
+	entryUser := widget.NewEntry()
+	entryPass := widget.NewPasswordEntry()
+	entryPass.PlaceHolder = "Contraseña de aplicacion"
+	hint := widget.NewLabel("Crea una contraseña de aplicacion en Ajustes > Seguridad de tu servidor.")
+	hint.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewForm("Contraseña de aplicacion", "Ok", "Cancelar", []*widget.FormItem{
+		widget.NewFormItem("", hint),
+		widget.NewFormItem("User:", entryUser),
+		widget.NewFormItem("Pass:", entryPass),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		user := strings.TrimSpace(entryUser.Text)
+		pass := entryPass.Text
+		go func() { finish(name, createNextcloudRemote(name, server, vendor, user, pass)) }()
+	}, w)
+	d.Resize(fyne.NewSize(480, 300))
+	d.Show()
+}
+
+// createNextcloudRemote crea el remote WebDAV. La ruta usa el identificador
+// del usuario, que no siempre coincide con el nombre de inicio de sesión.
+func createNextcloudRemote(name, server, vendor, loginName, appPassword string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	userID, err := nextcloud.UserID(ctx, server, loginName, appPassword)
+	if err != nil {
+		return err
+	}
+	return rclone.CreateConfigWithOpts(name, "webdav", map[string]string{
+		"url":    nextcloud.WebDAVURL(server, userID),
+		"vendor": vendor,
+		"user":   loginName,
+		"pass":   appPassword,
+	})
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/rclone"
+)
+
+// ShowOAuthWizard pide el nombre y lanza la autorización OAuth del proveedor
+func ShowOAuthWizard(w fyne.Window, title, provider string, finish func(name string, err error)) {
+	input := widget.NewEntry()
+	input.PlaceHolder = "Nombre"
+	input.Validator = rclone.NewRemoteNameValidator()
+	dialog.ShowForm("Configurar "+title, "Ok", "Cancelar", []*widget.FormItem{
+		widget.NewFormItem("Nombre:", input),
+	}, func(ok bool) {
+		if ok {
+			runOAuth(w, title, input.Text, provider, finish)
+		}
+	}, w)
+}
+
+// oauthFlow es una autorización OAuth en pantalla: con navegador (run) o
+// pegando el token generado en otra máquina (save)
+type oauthFlow struct {
+	title    string
+	provider string
+	run      func(ctx context.Context, onURL func(string)) error
+	save     func(token string) error
+	done     func(err error)
+	cancel   func()
+}
+
+// runOAuth crea el remote con el token de `rclone authorize`
+func runOAuth(w fyne.Window, title, name, provider string, finish func(string, error)) {
+	showOAuthScreen(w, oauthFlow{
+		title:    title,
+		provider: provider,
+		run: func(ctx context.Context, onURL func(string)) error {
+			token, err := rclone.Authorize(ctx, provider, onURL)
+			if err != nil {
+				return err
+			}
+			return rclone.CreateOAuthRemote(name, provider, token)
+		},
+		save: func(token string) error { return rclone.CreateOAuthRemote(name, provider, token) },
+		done: func(err error) {
+			if err == nil && provider == "onedrive" {
+				// Empresa y SharePoint: hay que elegir unidad/biblioteca
+				fyne.Do(func() { ShowOneDriveDrives(w, name, finish) })
+				return
+			}
+			finish(name, err)
+		},
+		cancel: func() { ShowCloudSelection(w) },
+	})
+}
+
+// showOAuthScreen muestra la URL de autorización y, para equipos sin
+// navegador, permite pegar el token generado en otra máquina
+func showOAuthScreen(w fyne.Window, f oauthFlow) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	status := widget.NewLabel("Iniciando rclone...")
+	status.Wrapping = fyne.TextWrapWord
+	link := widget.NewHyperlink("", nil)
+	link.Hide()
+	var authURL *url.URL
+	btnBrowser := widget.NewButtonWithIcon("Abrir navegador", theme.ComputerIcon(), func() {
+		if authURL != nil {
+			fyne.CurrentApp().OpenURL(authURL)
+		}
+	})
+	btnBrowser.Disable()
+
+	remoteCmd := rclone.AuthorizeCommand(f.provider)
+	lblRemote := widget.NewLabel("Sin navegador en este equipo? Ejecuta en otro:\n" + remoteCmd + "\ny pega aqui el resultado:")
+	btnCopy := widget.NewButtonWithIcon("", theme.ContentCopyIcon(), func() {
+		fyne.CurrentApp().Clipboard().SetContent(remoteCmd)
+	})
+	entryToken := widget.NewMultiLineEntry()
+	entryToken.SetMinRowsVisible(4)
+	entryToken.PlaceHolder = "{\"access_token\": ...}"
+
+	progress := widget.NewProgressBarInfinite()
+
+	// Solo cuenta el primero que termina: navegador, token pegado o cancelar
+	var once sync.Once
+	btnPaste := widget.NewButtonWithIcon("Usar token", theme.ConfirmIcon(), func() {
+		token, err := rclone.ExtractToken(entryToken.Text)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		once.Do(func() {
+			cancel()
+			status.SetText("Token recibido. Guardando...")
+			go func() { f.done(f.save(token)) }()
+		})
+	})
+	btnCancel := widget.NewButtonWithIcon("Cancelar", theme.CancelIcon(), func() {
+		once.Do(func() {
+			cancel()
+			f.cancel()
+		})
+	})
+
+	w.SetContent(container.NewVBox(
+		widget.NewLabelWithStyle("Autorizar "+f.title, fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		status,
+		link,
+		container.NewHBox(btnBrowser),
+		progress,
+		widget.NewSeparator(),
+		container.NewBorder(nil, nil, nil, btnCopy, lblRemote),
+		entryToken,
+		container.NewHBox(btnCancel, layout.NewSpacer(), btnPaste),
+	))
+
+	go func() {
+		err := f.run(ctx, func(u string) {
+			parsed, perr := url.Parse(u)
+			if perr != nil {
+				return
+			}
+			fyne.Do(func() {
+				authURL = parsed
+				status.SetText("Abre este enlace en el navegador de este equipo y autoriza el acceso:")
+				link.SetText(u)
+				link.SetURL(parsed)
+				link.Show()
+				btnBrowser.Enable()
+			})
+		})
+		if ctx.Err() != nil {
+			return // Cancelado, o ya se pegó un token
+		}
+		once.Do(func() {
+			cancel()
+			f.done(err)
+		})
+	}()
+}
+
+// reconnectRemote vuelve a autorizar un remote OAuth conservando sus
+// opciones y, si estaba montado, lo remonta para que use el token nuevo
+func reconnectRemote(w fyne.Window, name, provider string, wasMounted bool) {
+	showOAuthScreen(w, oauthFlow{
+		title:    name,
+		provider: provider,
+		run: func(ctx context.Context, onURL func(string)) error {
+			return rclone.Reconnect(ctx, name, onURL)
+		},
+		save: func(token string) error { return rclone.ReconnectWithToken(name, token) },
+		done: func(err error) {
+			if err == nil && wasMounted {
+				_, err = rclone.MountRemote(name)
+			}
+			fyne.Do(func() {
+				ShowDashboard(w)
+				if err != nil {
+					dialog.ShowError(err, w)
+				} else {
+					dialog.ShowInformation("Reconectado", "'"+name+"' vuelve a tener acceso.", w)
+				}
+			})
+		},
+		cancel: func() { ShowDashboard(w) },
+	})
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/onedrive"
+	"github.com/anabasasoft/cloudmount-wizard/internal/rclone"
+)
+
+// ShowOneDriveDrives lista las unidades de la cuenta (personal, empresa y
+// bibliotecas de SharePoint) tras autorizar. La primera elegida queda en el
+// remote autorizado; cada una de las demás se crea como unidad propia.
+func ShowOneDriveDrives(w fyne.Window, name string, finish func(name string, err error)) {
+	w.SetContent(container.NewVBox(layout.NewSpacer(), widget.NewLabel("Buscando bibliotecas de OneDrive..."), widget.NewProgressBarInfinite(), layout.NewSpacer()))
+
+	go func() {
+		conf, _ := rclone.GetRemoteConfig(name)
+		token, err := rclone.AccessToken(name)
+		if err != nil {
+			failOneDrive(name, conf["drive_id"], err, finish)
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+		drives, err := onedrive.NewClient(conf["region"], token).ListDrives(ctx)
+		if err != nil {
+			failOneDrive(name, conf["drive_id"], err, finish)
+			return
+		}
+		fyne.Do(func() { showOneDrivePicker(w, name, conf["drive_id"], drives, finish) })
+	}()
+}
+
+// failOneDrive informa del error. Un remote recien autorizado sin drive_id
+// no lo puede usar rclone, asi que se borra en lugar de dejarlo a medias.
+func failOneDrive(name, currentID string, err error, finish func(string, error)) {
+	if currentID == "" {
+		rclone.DeleteRemote(name)
+	}
+	finish(name, err)
+}
+
+// oneDriveDefaultName propone un nombre para una unidad extra. Las
+// bibliotecas de SharePoint casi siempre se llaman "Documents": se añade
+// el sitio y, si aun coincide con otra fila, un número.
+func oneDriveDefaultName(name string, d onedrive.Drive, used map[string]bool) string {
+	base := name + " - " + d.Name
+	if d.Site != "" {
+		base = name + " - " + d.Site + " - " + d.Name
+	}
+	candidate := base
+	for n := 2; used[candidate]; n++ {
+		candidate = fmt.Sprintf("%s %d", base, n)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+func showOneDrivePicker(w fyne.Window, name, currentID string, drives []onedrive.Drive, finish func(string, error)) {
+	checks := make([]*widget.Check, len(drives))
+	names := make([]*widget.Entry, len(drives))
+	list := container.NewVBox()
+	validateName := rclone.NewRemoteNameValidator()
+	used := map[string]bool{name: true}
+	for i, d := range drives {
+		checks[i] = widget.NewCheck(d.Label(), nil)
+		names[i] = widget.NewEntry()
+		names[i].SetText(oneDriveDefaultName(name, d, used))
+		names[i].Validator = validateName
+		list.Add(container.NewGridWithColumns(2, checks[i], names[i]))
+	}
+	// Marcamos la unidad que ya tiene el remote (o la del usuario)
+	preselected := 0
+	for i, d := range drives {
+		if d.ID == currentID {
+			preselected = i
+		}
+	}
+	checks[preselected].SetChecked(true)
+
+	btnSave := widget.NewButtonWithIcon("Guardar", theme.ConfirmIcon(), func() {
+		var chosen []int
+		for i, c := range checks {
+			if c.Checked {
+				chosen = append(chosen, i)
+			}
+		}
+		if len(chosen) == 0 {
+			dialog.ShowInformation("OneDrive", "Elige al menos una unidad.", w)
+			return
+		}
+		// Validate solo compara con los remotes que ya existen: config/create
+		// sobrescribiria en silencio dos filas con el mismo nombre
+		seen := map[string]bool{name: true}
+		for _, i := range chosen[1:] {
+			if err := names[i].Validate(); err != nil {
+				dialog.ShowError(fmt.Errorf("%s: %v", drives[i].Label(), err), w)
+				return
+			}
+			if seen[names[i].Text] {
+				dialog.ShowError(fmt.Errorf("el nombre '%s' esta repetido", names[i].Text), w)
+				return
+			}
+			seen[names[i].Text] = true
+		}
+		newNames := map[int]string{}
+		for _, i := range chosen[1:] {
+			newNames[i] = names[i].Text
+		}
+		w.SetContent(container.NewVBox(layout.NewSpacer(), widget.NewLabel("Guardando..."), widget.NewProgressBarInfinite(), layout.NewSpacer()))
+		go func() {
+			first := drives[chosen[0]]
+			if err := rclone.UpdateConfig(name, map[string]string{"drive_id": first.ID, "drive_type": first.Type}); err != nil {
+				failOneDrive(name, currentID, err, finish)
+				return
+			}
+			for _, i := range chosen[1:] {
+				d := drives[i]
+				if err := rclone.CloneRemote(name, newNames[i], map[string]string{"drive_id": d.ID, "drive_type": d.Type}); err != nil {
+					// El nombre no existia antes: no dejamos una copia a medias
+					rclone.DeleteRemote(newNames[i])
+					finish(name, fmt.Errorf("%s: %v", d.Label(), err))
+					return
+				}
+			}
+			finish(name, nil)
+		}()
+	})
+	btnSave.Importance = widget.HighImportance
+
+	hint := widget.NewLabel("La primera unidad marcada se usa en '" + name + "'. Las demas se crean como unidades nuevas con el nombre indicado.")
+	hint.Wrapping = fyne.TextWrapWord
+
+	top := container.NewVBox(
+		widget.NewLabelWithStyle("OneDrive: "+name, fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		hint,
+		widget.NewSeparator(),
+	)
+	bottom := container.NewHBox(
+		widget.NewButtonWithIcon("Omitir", theme.CancelIcon(), func() { skipOneDrivePicker(w, name, currentID, drives, finish) }),
+		layout.NewSpacer(),
+		btnSave,
+	)
+	w.SetContent(container.NewBorder(top, bottom, nil, nil, container.NewVScroll(list)))
+}
+
+// skipOneDrivePicker deja el remote como estaba o, si es nuevo, con la
+// unidad del usuario (/me/drive, la primera de ListDrives): sin drive_id
+// rclone no puede usarlo
+func skipOneDrivePicker(w fyne.Window, name, currentID string, drives []onedrive.Drive, finish func(string, error)) {
+	if currentID != "" || len(drives) == 0 {
+		finish(name, nil)
+		return
+	}
+	w.SetContent(container.NewVBox(layout.NewSpacer(), widget.NewLabel("Guardando..."), widget.NewProgressBarInfinite(), layout.NewSpacer()))
+	go func() {
+		d := drives[0]
+		if err := rclone.UpdateConfig(name, map[string]string{"drive_id": d.ID, "drive_type": d.Type}); err != nil {
+			failOneDrive(name, currentID, err, finish)
+			return
+		}
+		finish(name, nil)
+	}()
+}
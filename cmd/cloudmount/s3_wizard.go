@@ -0,0 +1,179 @@
+package main
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/rclone"
+	"github.com/anabasasoft/cloudmount-wizard/internal/settings"
+)
+
+// ShowS3Wizard pide las credenciales, las comprueba listando los buckets y
+// deja elegir bucket, carpeta y opciones de almacenamiento
+func ShowS3Wizard(w fyne.Window, finish func(name string, err error)) {
+	entryName := widget.NewEntry()
+	entryName.Validator = rclone.NewRemoteNameValidator()
+	selProvider := widget.NewSelect([]string{"AWS", "Minio", "Wasabi", "Other"}, nil)
+	selProvider.SetSelectedIndex(0)
+	entryAccess := widget.NewEntry()
+	entrySecret := widget.NewPasswordEntry()
+	entryEndpoint := widget.NewEntry()
+	entryEndpoint.PlaceHolder = "Vacio para AWS"
+
+	d := dialog.NewForm("Configurar S3", "Siguiente", "Cancelar", []*widget.FormItem{
+		widget.NewFormItem("Nombre:", entryName),
+		widget.NewFormItem("Prov:", selProvider),
+		widget.NewFormItem("Access:", entryAccess),
+		widget.NewFormItem("Secret:", entrySecret),
+		widget.NewFormItem("Endpoint:", entryEndpoint),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		creds := rclone.S3Credentials{
+			Provider:  selProvider.Selected,
+			AccessKey: strings.TrimSpace(entryAccess.Text),
+			SecretKey: strings.TrimSpace(entrySecret.Text),
+			Endpoint:  strings.TrimSpace(entryEndpoint.Text),
+		}
+		name := entryName.Text
+		w.SetContent(container.NewVBox(layout.NewSpacer(), widget.NewLabel("Comprobando credenciales..."), widget.NewProgressBarInfinite(), layout.NewSpacer()))
+		go func() {
+			buckets, err := rclone.ListBuckets(creds)
+			if err != nil {
+				finish(name, err)
+				return
+			}
+			fyne.Do(func() { showS3Browser(w, name, creds, buckets, finish) })
+		}()
+	}, w)
+	d.Resize(fyne.NewSize(500, 400))
+	d.Show()
+}
+
+// showS3Browser es el segundo paso: bucket, carpeta, región y opciones
+func showS3Browser(w fyne.Window, name string, creds rclone.S3Credentials, buckets []string, finish func(string, error)) {
+	var bucket, prefix string
+	var folders []string
+	var generation int // Descarta listados que llegan tarde
+
+	lblPath := widget.NewLabel("")
+	entryRegion := widget.NewEntry()
+	entryRegion.PlaceHolder = "Se detecta al elegir el bucket"
+
+	folderList := widget.NewList(
+		func() int { return len(folders) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(i widget.ListItemID, o fyne.CanvasObject) { o.(*widget.Label).SetText(folders[i] + "/") },
+	)
+
+	var browse func(p string)
+	browse = func(p string) {
+		prefix = p
+		lblPath.SetText("/" + strings.TrimSuffix(bucket+"/"+prefix, "/"))
+		folders = nil
+		folderList.UnselectAll()
+		folderList.Refresh()
+		generation++
+		b, gen := bucket, generation
+		go func() {
+			list, err := rclone.ListPrefixes(creds, b, p)
+			fyne.Do(func() {
+				if gen != generation {
+					return
+				}
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				folders = list
+				folderList.Refresh()
+			})
+		}()
+	}
+	folderList.OnSelected = func(i widget.ListItemID) {
+		browse(strings.TrimPrefix(prefix+"/"+folders[i], "/"))
+	}
+	btnUp := widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
+		if i := strings.LastIndex(prefix, "/"); i >= 0 {
+			browse(prefix[:i])
+		} else if prefix != "" {
+			browse("")
+		}
+	})
+
+	selBucket := widget.NewSelect(buckets, func(b string) {
+		bucket = b
+		browse("")
+		if creds.Provider != "AWS" {
+			return
+		}
+		entryRegion.SetText("")
+		go func() {
+			region, err := rclone.DetectBucketRegion(creds, b)
+			if err == nil {
+				fyne.Do(func() {
+					if bucket == b {
+						entryRegion.SetText(region)
+					}
+				})
+			}
+		}()
+	})
+
+	selClass := widget.NewSelect(rclone.S3StorageClasses, nil)
+	selClass.SetSelectedIndex(0)
+	selSSE := widget.NewSelect(rclone.S3Encryptions, nil)
+	selSSE.SetSelectedIndex(0)
+	selACL := widget.NewSelect(rclone.S3ACLs, nil)
+	selACL.SetSelectedIndex(0)
+
+	btnCreate := widget.NewButtonWithIcon("Crear unidad", theme.ConfirmIcon(), func() {
+		creds.Region = strings.TrimSpace(entryRegion.Text)
+		o := rclone.S3Options{StorageClass: selClass.Selected, Encryption: selSSE.Selected, ACL: selACL.Selected}
+		remotePath := strings.TrimSuffix(bucket+"/"+prefix, "/")
+		w.SetContent(container.NewVBox(layout.NewSpacer(), widget.NewLabel("Guardando..."), widget.NewProgressBarInfinite(), layout.NewSpacer()))
+		go func() {
+			if err := rclone.CreateS3(name, creds, o); err != nil {
+				finish(name, err)
+				return
+			}
+			if remotePath != "" {
+				opts := settings.GetOptions(name)
+				opts.RemotePath = remotePath
+				settings.SetOptions(name, opts)
+			}
+			finish(name, nil)
+		}()
+	})
+	btnCreate.Importance = widget.HighImportance
+
+	form := widget.NewForm(
+		widget.NewFormItem("Bucket:", selBucket),
+		widget.NewFormItem("Region:", entryRegion),
+		widget.NewFormItem("Clase:", selClass),
+		widget.NewFormItem("Cifrado:", selSSE),
+		widget.NewFormItem("ACL:", selACL),
+	)
+	top := container.NewVBox(
+		widget.NewLabelWithStyle("S3: "+name, fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		form,
+		widget.NewSeparator(),
+		container.NewBorder(nil, nil, btnUp, nil, lblPath),
+	)
+	bottom := container.NewHBox(
+		widget.NewButtonWithIcon("Cancelar", theme.CancelIcon(), func() { ShowCloudSelection(w) }),
+		layout.NewSpacer(),
+		btnCreate,
+	)
+	w.SetContent(container.NewBorder(top, bottom, nil, nil, folderList))
+	if len(buckets) == 1 {
+		selBucket.SetSelectedIndex(0)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/rclone"
+)
+
+// ShowServiceAccountWizard crea un remote de Google Drive o Cloud Storage con
+// una clave JSON de cuenta de servicio, sin pasar por el navegador
+func ShowServiceAccountWizard(w fyne.Window, finish func(name string, err error)) {
+	entryName := widget.NewEntry()
+	entryName.Validator = rclone.NewRemoteNameValidator()
+	entryKey, rowKey := fileEntry(w, "")
+	entryKey.PlaceHolder = "clave.json"
+	entryKey.Validator = func(path string) error {
+		data, err := os.ReadFile(strings.TrimSpace(path))
+		if err != nil {
+			return err
+		}
+		_, err = rclone.ParseServiceAccountKey(data)
+		return err
+	}
+	lblAccount := widget.NewLabel("")
+	entryKey.OnChanged = func(path string) {
+		lblAccount.SetText("")
+		if data, err := os.ReadFile(strings.TrimSpace(path)); err == nil {
+			if key, err := rclone.ParseServiceAccountKey(data); err == nil {
+				lblAccount.SetText(key.ClientEmail)
+			}
+		}
+	}
+
+	entryImpersonate := widget.NewEntry()
+	entryImpersonate.PlaceHolder = "usuario@tu-dominio.com (opcional)"
+	selBackend := widget.NewSelect([]string{"Google Drive", "Google Cloud Storage"}, func(s string) {
+		if s == "Google Drive" {
+			entryImpersonate.Enable()
+		} else {
+			entryImpersonate.Disable()
+		}
+	})
+	selBackend.SetSelectedIndex(0)
+
+	d := dialog.NewForm("Cuenta de servicio de Google", "Crear", "Cancelar", []*widget.FormItem{
+		widget.NewFormItem("Nombre:", entryName),
+		widget.NewFormItem("Tipo:", selBackend),
+		widget.NewFormItem("Clave JSON:", rowKey),
+		widget.NewFormItem("Cuenta:", lblAccount),
+		widget.NewFormItem("Actuar como:", entryImpersonate),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		name := entryName.Text
+		data, err := os.ReadFile(strings.TrimSpace(entryKey.Text))
+		if err != nil {
+			finish(name, err)
+			return
+		}
+		c := rclone.ServiceAccountConfig{Name: name, Backend: rclone.BackendDrive, Key: data}
+		if selBackend.Selected == "Google Drive" {
+			c.Impersonate = strings.TrimSpace(entryImpersonate.Text)
+		} else {
+			c.Backend = rclone.BackendGCS
+		}
+		go func() { finish(name, rclone.CreateServiceAccountRemote(c)) }()
+	}, w)
+	d.Resize(fyne.NewSize(560, 360))
+	d.Show()
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/rclone"
+	"github.com/anabasasoft/cloudmount-wizard/internal/sshutil"
+)
+
+var sftpAuthLabels = []string{"Contraseña", "Clave privada", "Agente SSH"}
+var sftpAuthModes = []string{rclone.SFTPAuthPassword, rclone.SFTPAuthKey, rclone.SFTPAuthAgent}
+
+// ShowSFTPWizard configura un servidor SFTP. finish recibe el nombre creado o el error.
+func ShowSFTPWizard(w fyne.Window, finish func(name string, err error)) {
+	entryName := widget.NewEntry()
+	entryName.Validator = rclone.NewRemoteNameValidator()
+	entryHost := widget.NewEntry()
+	entryHost.PlaceHolder = "servidor.ejemplo.com"
+	entryPort := widget.NewEntry()
+	entryPort.Text = "22"
+	entryPort.Validator = func(s string) error {
+		if p, err := strconv.Atoi(s); err != nil || p < 1 || p > 65535 {
+			return fmt.Errorf("puerto no valido")
+		}
+		return nil
+	}
+	entryUser := widget.NewEntry()
+	entryPass := widget.NewPasswordEntry()
+	entryKey := widget.NewEntry()
+	entryKey.PlaceHolder = "~/.ssh/id_ed25519"
+	entryKeyPass := widget.NewPasswordEntry()
+	entryKeyPass.PlaceHolder = "(si la clave tiene frase)"
+
+	btnBrowse := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		dialog.ShowFileOpen(func(rc fyne.URIReadCloser, err error) {
+			if err != nil || rc == nil {
+				return
+			}
+			rc.Close()
+			entryKey.SetText(rc.URI().Path())
+		}, w)
+	})
+
+	selAuth := widget.NewSelect(sftpAuthLabels, func(sel string) {
+		switch sel {
+		case "Contraseña":
+			entryPass.Enable()
+			entryKey.Disable()
+			entryKeyPass.Disable()
+			btnBrowse.Disable()
+		case "Clave privada":
+			entryPass.Disable()
+			entryKey.Enable()
+			entryKeyPass.Enable()
+			btnBrowse.Enable()
+		default:
+			entryPass.Disable()
+			entryKey.Enable()
+			entryKeyPass.Disable()
+			btnBrowse.Enable()
+		}
+	})
+	selAuth.SetSelectedIndex(0)
+
+	// Importar desde ~/.ssh/config
+	hosts := sshutil.LoadUserConfig()
+	var aliases []string
+	for _, h := range hosts {
+		aliases = append(aliases, h.Alias)
+	}
+	selImport := widget.NewSelect(aliases, func(sel string) {
+		for _, h := range hosts {
+			if h.Alias != sel {
+				continue
+			}
+			if entryName.Text == "" {
+				entryName.SetText(h.Alias)
+			}
+			entryHost.SetText(h.HostName)
+			if h.Port != "" {
+				entryPort.SetText(h.Port)
+			}
+			if h.User != "" {
+				entryUser.SetText(h.User)
+			}
+			if h.IdentityFile != "" {
+				entryKey.SetText(h.IdentityFile)
+				selAuth.SetSelected("Clave privada")
+			}
+		}
+	})
+	selImport.PlaceHolder = "(~/.ssh/config)"
+	if len(aliases) == 0 {
+		selImport.Disable()
+	}
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Importar:", selImport),
+		widget.NewFormItem("Nombre:", entryName),
+		widget.NewFormItem("Servidor:", entryHost),
+		widget.NewFormItem("Puerto:", entryPort),
+		widget.NewFormItem("Usuario:", entryUser),
+		widget.NewFormItem("Acceso:", selAuth),
+		widget.NewFormItem("Pass:", entryPass),
+		widget.NewFormItem("Clave:", container.NewBorder(nil, nil, nil, btnBrowse, entryKey)),
+		widget.NewFormItem("Frase:", entryKeyPass),
+	}
+
+	d := dialog.NewForm("Servidor SFTP", "Conectar", "Cancelar", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		port, _ := strconv.Atoi(entryPort.Text)
+		cfg := rclone.SFTPConfig{
+			Name:           entryName.Text,
+			Host:           strings.TrimSpace(entryHost.Text),
+			Port:           port,
+			User:           strings.TrimSpace(entryUser.Text),
+			Auth:           sftpAuthModes[selAuth.SelectedIndex()],
+			Password:       entryPass.Text,
+			KeyFile:        strings.TrimSpace(entryKey.Text),
+			KeyPassphrase:  entryKeyPass.Text,
+			KnownHostsFile: sshutil.KnownHostsPath(),
+		}
+		verifySFTPHost(w, cfg, finish)
+	}, w)
+	d.Resize(fyne.NewSize(550, 520))
+	d.Show()
+}
+
+// verifySFTPHost comprueba la clave del servidor contra known_hosts y, si es
+// nueva, muestra la huella para que el usuario decida si confiar (TOFU)
+func verifySFTPHost(w fyne.Window, cfg rclone.SFTPConfig, finish func(string, error)) {
+	w.SetContent(container.NewVBox(layout.NewSpacer(), widget.NewLabel("Comprobando la identidad del servidor..."), widget.NewProgressBarInfinite(), layout.NewSpacer()))
+
+	go func() {
+		err := sshutil.VerifyHost(cfg.KnownHostsFile, cfg.Host, cfg.Port, func(keys []sshutil.HostKey) bool {
+			var lines []string
+			for _, k := range keys {
+				lines = append(lines, k.Type+"  "+k.Fingerprint())
+			}
+			msg := fmt.Sprintf("Primera conexion con %s.\nComprueba que las huellas coinciden con las del servidor:\n\n%s\n\nConfiar y guardar en known_hosts?",
+				cfg.Host, strings.Join(lines, "\n"))
+			answer := make(chan bool, 1)
+			fyne.Do(func() {
+				dialog.ShowConfirm("Servidor desconocido", msg, func(ok bool) { answer <- ok }, w)
+			})
+			return <-answer
+		})
+		if errors.Is(err, sshutil.ErrHostNotTrusted) {
+			fyne.Do(func() { ShowCloudSelection(w) })
+			return
+		}
+		if err != nil {
+			finish(cfg.Name, err)
+			return
+		}
+		finish(cfg.Name, rclone.CreateSFTP(cfg))
+	}()
+}
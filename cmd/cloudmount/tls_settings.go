@@ -0,0 +1,154 @@
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/keyring"
+	"github.com/anabasasoft/cloudmount-wizard/internal/rclone"
+	"github.com/anabasasoft/cloudmount-wizard/internal/settings"
+)
+
+// verifyTLS es el modo --verify-tls: lo invoca la unidad systemd antes de montar
+func verifyTLS(remoteName string) int {
+	if rclone.IsConfigEncrypted() {
+		if pass, err := keyring.Lookup(configPassAttrs); err == nil {
+			rclone.SetConfigPassword(pass)
+		}
+	}
+	if err := rclone.VerifyServerTLS(remoteName); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// fileEntry es un campo de ruta con botón para elegir el archivo
+func fileEntry(w fyne.Window, value string) (*widget.Entry, fyne.CanvasObject) {
+	e := widget.NewEntry()
+	e.SetText(value)
+	btn := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		dialog.ShowFileOpen(func(rc fyne.URIReadCloser, err error) {
+			if err != nil || rc == nil {
+				return
+			}
+			rc.Close()
+			e.SetText(rc.URI().Path())
+		}, w)
+	})
+	return e, container.NewBorder(nil, nil, nil, btn, e)
+}
+
+// ShowTLSSettings edita CA propia, certificado cliente y certificado fijado
+func ShowTLSSettings(w fyne.Window, name string) {
+	opts := settings.GetOptions(name)
+
+	entryCA, rowCA := fileEntry(w, opts.CACert)
+	entryCert, rowCert := fileEntry(w, opts.ClientCert)
+	entryKey, rowKey := fileEntry(w, opts.ClientKey)
+
+	pinned := "Ninguno"
+	if opts.PinnedCert != "" {
+		pinned = opts.PinnedCert
+	}
+	lblPin := widget.NewLabel(pinned)
+	lblPin.Wrapping = fyne.TextWrapBreak
+
+	btnPin := widget.NewButtonWithIcon("Comprobar y fijar certificado del servidor", theme.ConfirmIcon(), func() {
+		offerTrustCert(w, name, nil)
+	})
+	btnUnpin := widget.NewButtonWithIcon("Quitar", theme.DeleteIcon(), func() {
+		o := settings.GetOptions(name)
+		o.PinnedCert = ""
+		settings.SetOptions(name, o)
+		go updateAutomount(w, name)
+		lblPin.SetText("Ninguno")
+	})
+
+	d := dialog.NewForm("TLS de "+name, "Guardar", "Cancelar", []*widget.FormItem{
+		widget.NewFormItem("CA propia:", rowCA),
+		widget.NewFormItem("Cert. cliente:", rowCert),
+		widget.NewFormItem("Clave cliente:", rowKey),
+		widget.NewFormItem("Fijado:", lblPin),
+		widget.NewFormItem("", container.NewHBox(btnPin, btnUnpin)),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		o := settings.GetOptions(name)
+		o.CACert = strings.TrimSpace(entryCA.Text)
+		o.ClientCert = strings.TrimSpace(entryCert.Text)
+		o.ClientKey = strings.TrimSpace(entryKey.Text)
+		settings.SetOptions(name, o)
+		go updateAutomount(w, name)
+		if (o.ClientCert == "") != (o.ClientKey == "") {
+			dialog.ShowInformation("TLS", "Para mTLS hacen falta el certificado y la clave.", w)
+		}
+	}, w)
+	d.Resize(fyne.NewSize(600, 380))
+	d.Show()
+}
+
+// offerTrustCert muestra el certificado del servidor y, si el usuario lo
+// acepta, lo fija (trust-on-first-use) y lo usa como CA del remote
+func offerTrustCert(w fyne.Window, name string, onTrusted func()) {
+	endpoint := rclone.TLSEndpoint(name)
+	if endpoint == "" {
+		dialog.ShowInformation("TLS", "Esta unidad no usa un servidor HTTPS.", w)
+		return
+	}
+	go func() {
+		certs, err := rclone.FetchServerCerts(endpoint, settings.GetOptions(name))
+		if err != nil {
+			fyne.Do(func() { dialog.ShowError(err, w) })
+			return
+		}
+		leaf := certs[0]
+		fingerprint := rclone.CertFingerprint(leaf)
+		msg := fmt.Sprintf("Servidor: %s\nSujeto: %s\nEmisor: %s\nValido hasta: %s\n\nHuella SHA256:\n%s\n\nConfiar en este certificado para '%s'?",
+			endpoint, leaf.Subject.String(), leaf.Issuer.String(), leaf.NotAfter.Format("2006-01-02"),
+			wrapFingerprint(fingerprint), name)
+		fyne.Do(func() {
+			dialog.ShowConfirm("Certificado del servidor", msg, func(ok bool) {
+				if ok {
+					if err := trustCert(name, certs, fingerprint); err != nil {
+						dialog.ShowError(err, w)
+						return
+					}
+					go updateAutomount(w, name)
+					if onTrusted != nil {
+						onTrusted()
+					}
+				}
+			}, w)
+		})
+	}()
+}
+
+func trustCert(name string, certs []*x509.Certificate, fingerprint string) error {
+	o := settings.GetOptions(name)
+	// La cadena aceptada se suma a la CA que ya hubiera: rclone solo ve --ca-cert
+	path, err := rclone.PinServerCerts(name, certs, o.CACert)
+	if err != nil {
+		return err
+	}
+	o.CACert = path
+	o.PinnedCert = fingerprint
+	return settings.SetOptions(name, o)
+}
+
+// wrapFingerprint parte la huella en dos líneas para que quepa en el diálogo
+func wrapFingerprint(fp string) string {
+	if len(fp) > 48 {
+		return fp[:48] + "\n" + fp[48:]
+	}
+	return fp
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/rclone"
+)
+
+// unionMemberRow es una fila del constructor: unidad, carpeta y (combine) nombre
+type unionMemberRow struct {
+	remote string
+	check  *widget.Check
+	path   *widget.Entry
+	dir    *widget.Entry
+}
+
+// ShowUnionWizard construye remotes union (fusionar cuentas) y combine (una carpeta por cuenta)
+func ShowUnionWizard(w fyne.Window) {
+	remotes, _ := rclone.ListRemotes()
+	if len(remotes) < 2 {
+		dialog.ShowInformation("Unir cuentas", "Necesitas al menos dos unidades configuradas.", w)
+		return
+	}
+
+	entryName := widget.NewEntry()
+	entryName.PlaceHolder = "Ej: TodasMisNubes"
+	entryName.Validator = rclone.NewRemoteNameValidator()
+
+	selAction := widget.NewSelect(rclone.UnionActionPolicies, nil)
+	selAction.SetSelectedIndex(0)
+	selCreate := widget.NewSelect(rclone.UnionCreatePolicies, nil)
+	selCreate.SetSelectedIndex(0)
+	selSearch := widget.NewSelect(rclone.UnionSearchPolicies, nil)
+	selSearch.SetSelectedIndex(0)
+	policies := widget.NewForm(
+		widget.NewFormItem("Acciones:", selAction),
+		widget.NewFormItem("Crear en:", selCreate),
+		widget.NewFormItem("Buscar:", selSearch),
+	)
+
+	var rows []*unionMemberRow
+	membersBox := container.NewVBox()
+	for _, r := range remotes {
+		row := &unionMemberRow{
+			remote: r,
+			check:  widget.NewCheck(r, nil),
+			path:   widget.NewEntry(),
+			dir:    widget.NewEntry(),
+		}
+		row.path.PlaceHolder = "carpeta (opcional)"
+		row.dir.SetText(rclone.MountDirName(r))
+		rows = append(rows, row)
+		membersBox.Add(container.NewGridWithColumns(3, row.check, row.path, row.dir))
+	}
+
+	isUnion := true
+	modeRadio := widget.NewRadioGroup([]string{"Union (fusionar en una sola carpeta)", "Combine (una carpeta por cuenta)"}, func(sel string) {
+		isUnion = strings.HasPrefix(sel, "Union")
+		for _, row := range rows {
+			if isUnion {
+				row.dir.Hide()
+			} else {
+				row.dir.Show()
+			}
+		}
+		if isUnion {
+			policies.Show()
+		} else {
+			policies.Hide()
+		}
+	})
+	modeRadio.SetSelected("Union (fusionar en una sola carpeta)")
+
+	errLabel := widget.NewLabel("")
+	errLabel.Hide()
+
+	// prepare valida el formulario en el hilo de la UI y devuelve la creación
+	prepare := func() (func() error, error) {
+		if err := entryName.Validate(); err != nil {
+			return nil, err
+		}
+		name := entryName.Text
+		var upstreams []string
+		dirs := make(map[string]string)
+		for _, row := range rows {
+			if !row.check.Checked {
+				continue
+			}
+			target := row.remote + ":" + strings.Trim(row.path.Text, "/")
+			upstreams = append(upstreams, target)
+			if !isUnion {
+				if _, dup := dirs[row.dir.Text]; dup {
+					return nil, errors.New("hay carpetas repetidas: '" + row.dir.Text + "'")
+				}
+				dirs[row.dir.Text] = target
+			}
+		}
+		if isUnion {
+			action, create, search := selAction.Selected, selCreate.Selected, selSearch.Selected
+			return func() error { return rclone.CreateUnion(name, upstreams, action, create, search) }, nil
+		}
+		return func() error { return rclone.CreateCombine(name, dirs) }, nil
+	}
+
+	showErr := func(err error) {
+		errLabel.SetText(err.Error())
+		errLabel.Show()
+	}
+
+	btnCreate := widget.NewButtonWithIcon("Crear", theme.ConfirmIcon(), nil)
+	btnCreate.OnTapped = func() {
+		create, err := prepare()
+		if err != nil {
+			showErr(err)
+			return
+		}
+		btnCreate.Disable()
+		go func() {
+			err := create()
+			fyne.Do(func() {
+				btnCreate.Enable()
+				if err != nil {
+					showErr(err)
+					return
+				}
+				ShowDashboard(w)
+			})
+		}()
+	}
+
+	content := container.NewVBox(
+		widget.NewLabelWithStyle("Unir cuentas", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		widget.NewForm(widget.NewFormItem("Nombre:", entryName)),
+		modeRadio,
+		widget.NewSeparator(),
+		widget.NewLabel("Miembros:"),
+		membersBox,
+		policies,
+		errLabel,
+		widget.NewSeparator(),
+		container.NewHBox(
+			widget.NewButtonWithIcon("Volver", theme.CancelIcon(), func() { ShowCloudSelection(w) }),
+			layout.NewSpacer(),
+			btnCreate,
+		),
+	)
+	w.SetContent(container.NewPadded(container.NewVScroll(content)))
+}
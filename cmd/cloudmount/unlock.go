@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/keyring"
+	"github.com/anabasasoft/cloudmount-wizard/internal/rclone"
+)
+
+// Atributos con los que guardamos la contraseña de rclone.conf en el llavero
+var configPassAttrs = map[string]string{
+	"application": "com.anabasasoft.cloudmount",
+	"item":        "rclone-config-password",
+}
+
+// printConfigPassword es el modo --config-pass: rclone lo invoca como
+// --password-command desde las unidades systemd
+func printConfigPassword() int {
+	pass, err := keyring.Lookup(configPassAttrs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println(pass)
+	return 0
+}
+
+// usePasswordCommand hace que las unidades systemd lean la contraseña del
+// llavero a través de este mismo ejecutable
+func usePasswordCommand() {
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+	if strings.ContainsAny(exe, " \"") {
+		exe = `"` + strings.ReplaceAll(exe, `"`, `""`) + `"`
+	}
+	rclone.SetPasswordCommand(exe + " --config-pass")
+}
+
+// unlockConfig obtiene la contraseña de rclone.conf (llavero o diálogo)
+// y llama a onReady cuando rclone ya puede leer la configuración
+func unlockConfig(w fyne.Window, onReady func()) {
+	w.SetContent(container.NewCenter(widget.NewLabel("Desbloqueando configuracion...")))
+	go func() {
+		pass, err := keyring.Lookup(configPassAttrs)
+		if err == nil && rclone.VerifyConfigPassword(pass) == nil {
+			rclone.SetConfigPassword(pass)
+			usePasswordCommand()
+			fyne.Do(onReady)
+			return
+		}
+		fyne.Do(func() { askConfigPassword(w, "", onReady) })
+	}()
+}
+
+func askConfigPassword(w fyne.Window, lastErr string, onReady func()) {
+	w.Show()
+
+	entryPass := widget.NewPasswordEntry()
+	checkKeyring := widget.NewCheck("Recordar en el llavero del sistema", nil)
+	checkKeyring.Checked = true
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Contraseña:", entryPass),
+		widget.NewFormItem("", checkKeyring),
+	}
+	if lastErr != "" {
+		items = append(items, widget.NewFormItem("", widget.NewLabel(lastErr)))
+	}
+
+	d := dialog.NewForm("Configuracion de rclone cifrada", "Desbloquear", "Cancelar", items, func(ok bool) {
+		if !ok {
+			showLockedScreen(w, onReady)
+			return
+		}
+		pass := entryPass.Text
+		remember := checkKeyring.Checked
+		w.SetContent(container.NewCenter(widget.NewLabel("Comprobando contraseña...")))
+		go func() {
+			if err := rclone.VerifyConfigPassword(pass); err != nil {
+				fyne.Do(func() { askConfigPassword(w, err.Error(), onReady) })
+				return
+			}
+			rclone.SetConfigPassword(pass)
+
+			var storeErr error
+			if remember {
+				if storeErr = keyring.Store("CloudMount: contraseña de rclone.conf", configPassAttrs, pass); storeErr == nil {
+					usePasswordCommand()
+				}
+			}
+			fyne.Do(func() {
+				onReady()
+				if storeErr != nil {
+					dialog.ShowError(fmt.Errorf("No se pudo guardar en el llavero: %v", storeErr), w)
+				}
+			})
+		}()
+	}, w)
+	d.Resize(fyne.NewSize(450, 250))
+	d.Show()
+	w.Canvas().Focus(entryPass)
+}
+
+func showLockedScreen(w fyne.Window, onReady func()) {
+	w.SetContent(container.NewCenter(container.NewVBox(
+		widget.NewLabelWithStyle("Configuracion bloqueada", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		widget.NewLabel("rclone.conf esta cifrado. Sin la contraseña no se pueden usar las unidades."),
+		widget.NewButtonWithIcon("Desbloquear", theme.LoginIcon(), func() { askConfigPassword(w, "", onReady) }),
+	)))
+}
@@ -0,0 +1,254 @@
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Cliente mínimo del API Secret Service (org.freedesktop.secrets) por D-Bus.
+// Lo implementan GNOME Keyring, KWallet (>= 5.97) y KeePassXC.
+
+const (
+	serviceName    = "org.freedesktop.secrets"
+	servicePath    = dbus.ObjectPath("/org/freedesktop/secrets")
+	defaultAlias   = dbus.ObjectPath("/org/freedesktop/secrets/aliases/default")
+	ifaceService   = "org.freedesktop.Secret.Service"
+	ifaceCollect   = "org.freedesktop.Secret.Collection"
+	ifaceItem      = "org.freedesktop.Secret.Item"
+	ifacePrompt    = "org.freedesktop.Secret.Prompt"
+	ifaceSession   = "org.freedesktop.Secret.Session"
+	promptTimeout  = 2 * time.Minute
+	noPrompt       = dbus.ObjectPath("/")
+	labelProperty  = ifaceItem + ".Label"
+	attrsProperty  = ifaceItem + ".Attributes"
+	plainAlgorithm = "plain"
+)
+
+// ErrNotFound indica que no hay ningún secreto con esos atributos
+var ErrNotFound = errors.New("secreto no encontrado en el llavero")
+
+// secret es la estructura (oayays) que usa el API para transportar secretos
+type secret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// Client habla con un servicio de secretos sobre una conexión D-Bus.
+type Client struct {
+	conn    *dbus.Conn
+	private bool
+}
+
+// Open conecta con el bus de sesión del usuario
+func Open() (*Client, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo conectar al bus de sesión: %v", err)
+	}
+	return &Client{conn: conn, private: true}, nil
+}
+
+// NewClient usa una conexión ya abierta (por ejemplo, un bus privado con un
+// servicio de secretos falso para pruebas). Close no la cierra.
+func NewClient(conn *dbus.Conn) *Client {
+	return &Client{conn: conn}
+}
+
+// Close libera la conexión si la abrió Open
+func (c *Client) Close() error {
+	if c.private {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+func (c *Client) service() dbus.BusObject {
+	return c.conn.Object(serviceName, servicePath)
+}
+
+// openSession abre una sesión sin cifrado de transporte (el bus de sesión
+// ya es local y solo accesible por el usuario)
+func (c *Client) openSession() (dbus.ObjectPath, error) {
+	var output dbus.Variant
+	var session dbus.ObjectPath
+	err := c.service().Call(ifaceService+".OpenSession", 0, plainAlgorithm, dbus.MakeVariant("")).Store(&output, &session)
+	if err != nil {
+		return "", fmt.Errorf("error abriendo sesión del llavero: %v", err)
+	}
+	return session, nil
+}
+
+func (c *Client) closeSession(session dbus.ObjectPath) {
+	c.conn.Object(serviceName, session).Call(ifaceSession+".Close", 0)
+}
+
+// prompt ejecuta un Prompt del servicio (p.ej. pedir la clave del llavero)
+// y espera a que el usuario lo complete
+func (c *Client) prompt(path dbus.ObjectPath) error {
+	if path == noPrompt || path == "" {
+		return nil
+	}
+	match := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(path),
+		dbus.WithMatchInterface(ifacePrompt),
+		dbus.WithMatchMember("Completed"),
+	}
+	if err := c.conn.AddMatchSignal(match...); err != nil {
+		return err
+	}
+	defer c.conn.RemoveMatchSignal(match...)
+
+	ch := make(chan *dbus.Signal, 4)
+	c.conn.Signal(ch)
+	defer c.conn.RemoveSignal(ch)
+
+	if err := c.conn.Object(serviceName, path).Call(ifacePrompt+".Prompt", 0, "").Err; err != nil {
+		return err
+	}
+
+	timeout := time.After(promptTimeout)
+	for {
+		select {
+		case sig := <-ch:
+			if sig.Path != path || len(sig.Body) == 0 {
+				continue
+			}
+			if dismissed, _ := sig.Body[0].(bool); dismissed {
+				return fmt.Errorf("operación cancelada en el llavero")
+			}
+			return nil
+		case <-timeout:
+			return fmt.Errorf("tiempo de espera agotado en el llavero")
+		}
+	}
+}
+
+func (c *Client) unlock(paths []dbus.ObjectPath) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	var unlocked []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	if err := c.service().Call(ifaceService+".Unlock", 0, paths).Store(&unlocked, &prompt); err != nil {
+		return fmt.Errorf("error desbloqueando el llavero: %v", err)
+	}
+	return c.prompt(prompt)
+}
+
+func (c *Client) search(attrs map[string]string) ([]dbus.ObjectPath, error) {
+	var unlocked, locked []dbus.ObjectPath
+	if err := c.service().Call(ifaceService+".SearchItems", 0, attrs).Store(&unlocked, &locked); err != nil {
+		return nil, fmt.Errorf("error buscando en el llavero: %v", err)
+	}
+	if err := c.unlock(locked); err != nil {
+		return nil, err
+	}
+	return append(unlocked, locked...), nil
+}
+
+// Lookup devuelve el secreto guardado con esos atributos
+func (c *Client) Lookup(attrs map[string]string) (string, error) {
+	items, err := c.search(attrs)
+	if err != nil {
+		return "", err
+	}
+	if len(items) == 0 {
+		return "", ErrNotFound
+	}
+
+	session, err := c.openSession()
+	if err != nil {
+		return "", err
+	}
+	defer c.closeSession(session)
+
+	var s secret
+	if err := c.conn.Object(serviceName, items[0]).Call(ifaceItem+".GetSecret", 0, session).Store(&s); err != nil {
+		return "", fmt.Errorf("error leyendo el secreto: %v", err)
+	}
+	return string(s.Value), nil
+}
+
+// Store guarda (o reemplaza) un secreto en la colección por defecto
+func (c *Client) Store(label string, attrs map[string]string, value string) error {
+	var collection dbus.ObjectPath
+	if err := c.service().Call(ifaceService+".ReadAlias", 0, "default").Store(&collection); err != nil || collection == noPrompt {
+		collection = defaultAlias
+	}
+	if err := c.unlock([]dbus.ObjectPath{collection}); err != nil {
+		return err
+	}
+
+	session, err := c.openSession()
+	if err != nil {
+		return err
+	}
+	defer c.closeSession(session)
+
+	props := map[string]dbus.Variant{
+		labelProperty: dbus.MakeVariant(label),
+		attrsProperty: dbus.MakeVariant(attrs),
+	}
+	s := secret{Session: session, Parameters: []byte{}, Value: []byte(value), ContentType: "text/plain; charset=utf8"}
+
+	var item, prompt dbus.ObjectPath
+	if err := c.conn.Object(serviceName, collection).Call(ifaceCollect+".CreateItem", 0, props, s, true).Store(&item, &prompt); err != nil {
+		return fmt.Errorf("error guardando en el llavero: %v", err)
+	}
+	return c.prompt(prompt)
+}
+
+// Delete borra todos los secretos con esos atributos
+func (c *Client) Delete(attrs map[string]string) error {
+	items, err := c.search(attrs)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		var prompt dbus.ObjectPath
+		if err := c.conn.Object(serviceName, item).Call(ifaceItem+".Delete", 0).Store(&prompt); err != nil {
+			return fmt.Errorf("error borrando del llavero: %v", err)
+		}
+		if err := c.prompt(prompt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// --- ATAJOS SOBRE EL BUS DE SESIÓN ---
+
+// Lookup busca un secreto en el llavero del usuario
+func Lookup(attrs map[string]string) (string, error) {
+	c, err := Open()
+	if err != nil {
+		return "", err
+	}
+	defer c.Close()
+	return c.Lookup(attrs)
+}
+
+// Store guarda un secreto en el llavero del usuario
+func Store(label string, attrs map[string]string, value string) error {
+	c, err := Open()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return c.Store(label, attrs, value)
+}
+
+// Delete borra un secreto del llavero del usuario
+func Delete(attrs map[string]string) error {
+	c, err := Open()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return c.Delete(attrs)
+}
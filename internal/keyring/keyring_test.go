@@ -0,0 +1,327 @@
+package keyring
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// busConfig es un bus de sesión mínimo y sin restricciones para las pruebas
+const busConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:dir=%s</listen>
+  <policy context="default">
+    <allow send_destination="*" eavesdrop="true"/>
+    <allow eavesdrop="true"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>`
+
+// startBus lanza un dbus-daemon privado y devuelve su dirección
+func startBus(t *testing.T) string {
+	t.Helper()
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon no disponible")
+	}
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "bus.conf")
+	if err := os.WriteFile(conf, []byte(fmt.Sprintf(busConfig, dir)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(daemon, "--config-file="+conf, "--nofork", "--print-address")
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	line, err := bufio.NewReader(out).ReadString('\n')
+	if err != nil {
+		t.Fatalf("dbus-daemon no dio dirección: %v", err)
+	}
+	return strings.TrimSpace(line)
+}
+
+// fakeService imita lo justo de org.freedesktop.secrets: una colección por
+// defecto, items en memoria y prompts que se completan o se cancelan
+type fakeService struct {
+	conn *dbus.Conn
+
+	mu       sync.Mutex
+	items    map[dbus.ObjectPath]*fakeItem
+	next     int
+	locked   bool // La colección pide desbloqueo con un prompt
+	dismiss  bool // Los prompts se cancelan
+	sessions int  // Sesiones abiertas y no cerradas
+	prompts  int
+}
+
+type fakeItem struct {
+	svc   *fakeService
+	path  dbus.ObjectPath
+	attrs map[string]string
+	value []byte
+}
+
+func newFakeService(t *testing.T, addr string) *fakeService {
+	t.Helper()
+	conn, err := dbus.Connect(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	svc := &fakeService{conn: conn, items: map[dbus.ObjectPath]*fakeItem{}}
+	conn.Export(svc, servicePath, ifaceService)
+	conn.Export((*fakeCollection)(svc), defaultAlias, ifaceCollect)
+	reply, err := conn.RequestName(serviceName, dbus.NameFlagDoNotQueue)
+	if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("no se pudo registrar %s: %v", serviceName, err)
+	}
+	return svc
+}
+
+func (s *fakeService) OpenSession(algorithm string, input dbus.Variant) (dbus.Variant, dbus.ObjectPath, *dbus.Error) {
+	if algorithm != plainAlgorithm {
+		return dbus.MakeVariant(""), "", dbus.MakeFailedError(fmt.Errorf("algoritmo no soportado: %s", algorithm))
+	}
+	s.mu.Lock()
+	s.sessions++
+	s.next++
+	path := dbus.ObjectPath(fmt.Sprintf("/org/freedesktop/secrets/session/%d", s.next))
+	s.mu.Unlock()
+	s.conn.Export(fakeSession{s, path}, path, ifaceSession)
+	return dbus.MakeVariant(""), path, nil
+}
+
+func (s *fakeService) ReadAlias(name string) (dbus.ObjectPath, *dbus.Error) {
+	return defaultAlias, nil
+}
+
+func (s *fakeService) SearchItems(attrs map[string]string) ([]dbus.ObjectPath, []dbus.ObjectPath, *dbus.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var unlocked, locked []dbus.ObjectPath
+	for path, item := range s.items {
+		if matches(item.attrs, attrs) {
+			if s.locked {
+				locked = append(locked, path)
+			} else {
+				unlocked = append(unlocked, path)
+			}
+		}
+	}
+	return unlocked, locked, nil
+}
+
+func (s *fakeService) Unlock(paths []dbus.ObjectPath) ([]dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.locked {
+		return paths, noPrompt, nil
+	}
+	s.next++
+	path := dbus.ObjectPath(fmt.Sprintf("/org/freedesktop/secrets/prompt/%d", s.next))
+	s.conn.Export(fakePrompt{s, path}, path, ifacePrompt)
+	return nil, path, nil
+}
+
+type fakeSession struct {
+	svc  *fakeService
+	path dbus.ObjectPath
+}
+
+func (f fakeSession) Close() *dbus.Error {
+	f.svc.mu.Lock()
+	f.svc.sessions--
+	f.svc.mu.Unlock()
+	f.svc.conn.Export(nil, f.path, ifaceSession)
+	return nil
+}
+
+type fakePrompt struct {
+	svc  *fakeService
+	path dbus.ObjectPath
+}
+
+// Prompt responde y después emite Completed, como los servicios reales
+func (p fakePrompt) Prompt(windowID string) *dbus.Error {
+	p.svc.mu.Lock()
+	p.svc.prompts++
+	dismissed := p.svc.dismiss
+	if !dismissed {
+		p.svc.locked = false
+	}
+	p.svc.mu.Unlock()
+	go p.svc.conn.Emit(p.path, ifacePrompt+".Completed", dismissed, dbus.MakeVariant(""))
+	return nil
+}
+
+type fakeCollection fakeService
+
+func (c *fakeCollection) CreateItem(props map[string]dbus.Variant, s secret, replace bool) (dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	svc := (*fakeService)(c)
+	var attrs map[string]string
+	if err := props[attrsProperty].Store(&attrs); err != nil {
+		return "", "", dbus.MakeFailedError(err)
+	}
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if replace {
+		for path, item := range svc.items {
+			if matches(item.attrs, attrs) && len(item.attrs) == len(attrs) {
+				item.value = s.Value
+				return path, noPrompt, nil
+			}
+		}
+	}
+	svc.next++
+	path := dbus.ObjectPath(fmt.Sprintf("/org/freedesktop/secrets/collection/login/%d", svc.next))
+	item := &fakeItem{svc: svc, path: path, attrs: attrs, value: s.Value}
+	svc.items[path] = item
+	svc.conn.Export(item, path, ifaceItem)
+	return path, noPrompt, nil
+}
+
+func (i *fakeItem) GetSecret(session dbus.ObjectPath) (secret, *dbus.Error) {
+	i.svc.mu.Lock()
+	defer i.svc.mu.Unlock()
+	return secret{Session: session, Parameters: []byte{}, Value: i.value, ContentType: "text/plain"}, nil
+}
+
+func (i *fakeItem) Delete() (dbus.ObjectPath, *dbus.Error) {
+	i.svc.mu.Lock()
+	delete(i.svc.items, i.path)
+	i.svc.mu.Unlock()
+	i.svc.conn.Export(nil, i.path, ifaceItem)
+	return noPrompt, nil
+}
+
+func matches(have, want map[string]string) bool {
+	for k, v := range want {
+		if have[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func newTestClient(t *testing.T) (*Client, *fakeService) {
+	t.Helper()
+	addr := startBus(t)
+	svc := newFakeService(t, addr)
+	conn, err := dbus.Connect(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewClient(conn), svc
+}
+
+var testAttrs = map[string]string{"application": "cloudmount", "kind": "rclone-config"}
+
+func TestStoreLookupDelete(t *testing.T) {
+	c, svc := newTestClient(t)
+
+	if _, err := c.Lookup(testAttrs); err != ErrNotFound {
+		t.Fatalf("Lookup sin secreto: esperaba ErrNotFound, obtuve %v", err)
+	}
+	if err := c.Store("prueba", testAttrs, "primera"); err != nil {
+		t.Fatal(err)
+	}
+	// Guardar otra vez reemplaza en lugar de duplicar
+	if err := c.Store("prueba", testAttrs, "segunda"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.Lookup(testAttrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "segunda" {
+		t.Fatalf("Lookup = %q, esperaba %q", got, "segunda")
+	}
+	svc.mu.Lock()
+	n := len(svc.items)
+	svc.mu.Unlock()
+	if n != 1 {
+		t.Fatalf("hay %d secretos, esperaba 1", n)
+	}
+
+	if err := c.Delete(testAttrs); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Lookup(testAttrs); err != ErrNotFound {
+		t.Fatalf("Lookup tras Delete: esperaba ErrNotFound, obtuve %v", err)
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if svc.sessions != 0 {
+		t.Fatalf("quedan %d sesiones sin cerrar", svc.sessions)
+	}
+}
+
+func TestLockedCollectionPrompts(t *testing.T) {
+	c, svc := newTestClient(t)
+	if err := c.Store("prueba", testAttrs, "secreto"); err != nil {
+		t.Fatal(err)
+	}
+
+	svc.mu.Lock()
+	svc.locked = true
+	svc.mu.Unlock()
+
+	got, err := c.Lookup(testAttrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "secreto" {
+		t.Fatalf("Lookup = %q, esperaba %q", got, "secreto")
+	}
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if svc.prompts != 1 {
+		t.Fatalf("se mostraron %d prompts, esperaba 1", svc.prompts)
+	}
+}
+
+func TestDismissedPrompt(t *testing.T) {
+	c, svc := newTestClient(t)
+	if err := c.Store("prueba", testAttrs, "secreto"); err != nil {
+		t.Fatal(err)
+	}
+
+	svc.mu.Lock()
+	svc.locked = true
+	svc.dismiss = true
+	svc.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Lookup(testAttrs)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "cancelada") {
+			t.Fatalf("esperaba error de operación cancelada, obtuve %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Lookup no volvió tras cancelar el prompt")
+	}
+}
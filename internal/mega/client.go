@@ -2,63 +2,77 @@ package mega
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
-	"syscall" // CLAVE: Necesario para desacoplar el proceso (Setsid: true)
-"time"    // Necesario para esperar el arranque del servidor
+	"time"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/redact"
 )
 
-// EnsureDaemon asegura que el servidor de Mega esté corriendo independiente de la App
+// EnsureDaemon asegura que el servidor de Mega esté corriendo independiente
+// de la App y deja al supervisor vigilándolo
 func EnsureDaemon() error {
-	// 1. Probamos si ya responde (para no lanzar otro proceso)
-	if err := exec.Command("mega-whoami").Run(); err == nil {
-		return nil // Ya está corriendo
-	}
-
-	// 2. Si no responde, lo iniciamos DESACOPLADO
-	cmd := exec.Command("mega-cmd-server")
-
-	// CLAVE DE PERSISTENCIA: Setsid: true crea una nueva sesión.
-	// El proceso deja de ser hijo de tu app y el sistema no lo mata al cerrar.
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setsid: true,
-	}
-
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("error iniciando servidor mega: %v", err)
+	if err := Daemon.Ensure(); err != nil {
+		return err
 	}
-
-	// Damos unos segundos para que arranque antes de seguir
-	time.Sleep(2 * time.Second)
+	Daemon.Watch()
 	return nil
 }
 
-// Login conecta usando la sintaxis correcta (--auth-code al final)
+// ErrNeeds2FA indica que la cuenta tiene la verificación en dos pasos
+// activada y mega-login necesita el código
+var ErrNeeds2FA = errors.New("la cuenta de MEGA pide el codigo de verificacion en dos pasos")
+
+// need2FARe reconoce la respuesta de mega-login cuando falta el código 2FA:
+// el aviso en texto, la pregunta por el código de la app de autenticación
+// o el error del API (API_EMFAREQUIRED, código -26)
+var need2FARe = regexp.MustCompile(`(?i)(two.?factor|2fa|multi.?factor|\bmfa\b|authentication (code|app)|auth.?code|API_EMFAREQUIRED|(^|[^\w-])-26\b)`)
+
+// Login conecta usando la sintaxis correcta (--auth-code al final).
+// La contraseña no va en los argumentos (visibles con ps): mega-login la
+// pide cuando solo recibe el email y se la damos por stdin.
+// Si ya hay sesión con esa cuenta se reutiliza; si la cuenta pide 2FA y
+// no se ha dado código devuelve ErrNeeds2FA.
 func Login(user, pass, code2FA string) error {
 	EnsureDaemon() // Aseguramos que el servidor exista antes de intentar login
-	exec.Command("mega-logout").Run()
+	current := CurrentAccount()
+	if current != "" && strings.EqualFold(current, user) {
+		return nil
+	}
+	if current != "" {
+		megaCommand("mega-logout").Run()
+	}
 
-	args := []string{user, pass}
+	args := []string{user}
 	if code2FA != "" {
 		args = append(args, "--auth-code="+code2FA)
 	}
 
-	cmd := exec.Command("mega-login", args...)
+	cmd := megaCommand("mega-login", args...)
+	cmd.Stdin = strings.NewReader(pass + "\n")
 	if out, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("falló mega-login: %s", string(out))
+		if code2FA == "" && need2FARe.Match(out) {
+			return ErrNeeds2FA
+		}
+		return fmt.Errorf("falló mega-login: %s", redact.Secrets(string(out), pass))
 	}
 	return nil
 }
 
-// GetWebDAVURL activa el servidor local de Mega
-func GetWebDAVURL() (string, error) {
+// GetWebDAVURL sirve una carpeta de MEGA ("/" = toda la cuenta) por el
+// servidor WebDAV local y devuelve su URL. Cada carpeta tiene la suya.
+func GetWebDAVURL(path string) (string, error) {
 	EnsureDaemon() // Aseguramos que el servidor exista antes de intentar usarlo
-	cmd := exec.Command("mega-webdav", "/")
+	if path == "" {
+		path = "/"
+	}
+	cmd := megaCommand("mega-webdav", path)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return "", fmt.Errorf("error webdav: %s", string(output))
@@ -74,55 +88,154 @@ func GetWebDAVURL() (string, error) {
 	return "", fmt.Errorf("no se encontró URL en: %s", outStr)
 }
 
-// GetSpace analiza la salida exacta de mega-df que nos has pasado
-func GetSpace() (int64, int64, error) {
-	// Ejecutamos mega-df. En Linux forzamos inglés por seguridad,
-	// pero el formato numérico suele ser estándar.
-	cmd := exec.Command("mega-df")
+// lsDirRe reconoce las carpetas en `mega-ls -l`:
+// FLAGS VERS SIZE FECHA HORA NOMBRE, con FLAGS empezando por "d"
+var lsDirRe = regexp.MustCompile(`^d\S*\s+\S+\s+\S+\s+\S+\s+\S+\s+(.+)$`)
+
+// ListFolders devuelve las subcarpetas de una ruta de MEGA
+func ListFolders(path string) ([]string, error) {
+	out, err := run("mega-ls", "-l", path)
+	if err != nil {
+		return nil, err
+	}
+	var folders []string
+	for _, line := range strings.Split(out, "\n") {
+		if m := lsDirRe.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			folders = append(folders, m[1])
+		}
+	}
+	return folders, nil
+}
+
+// Space es el desglose de `mega-df`, en bytes
+type Space struct {
+	Used       int64
+	Total      int64
+	CloudDrive int64 // Unidad en la nube (ROOT)
+	Inbox      int64 // Bandeja de entrada (Vault en versiones nuevas)
+	Rubbish    int64 // Papelera
+	Versions   int64 // Versiones anteriores de archivos
+}
+
+var (
+	dfUsedRe     = regexp.MustCompile(`USED STORAGE:\s*(\d+).*of\s*(\d+)`)
+	dfSectionRe  = regexp.MustCompile(`(?i)^(?:In\s+)?(ROOT|Cloud drive|INBOX|Inbox|Vault|RUBBISH|Rubbish bin):\s*(\d+)`)
+	dfVersionsRe = regexp.MustCompile(`(?i)file versions:\s*(\d+)`)
+)
+
+// GetSpace ejecuta mega-df y devuelve el total y el desglose por secciones
+func GetSpace() (*Space, error) {
+	// En Linux forzamos inglés: las etiquetas que buscamos van en inglés
+	cmd := megaCommand("mega-df")
 	if os.Getenv("OS") != "Windows_NT" {
 		cmd.Env = append(os.Environ(), "LC_ALL=C")
 	}
 	output, err := cmd.Output()
 	if err != nil {
-		return 0, 0, err
+		return nil, err
 	}
+	return parseDF(string(output))
+}
 
-	// Buscamos la línea que dice "USED STORAGE"
-	// Ejemplo: "USED STORAGE:   78281147   0.15% of 53687091200"
-	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+// parseDF lee la salida de mega-df. Ejemplo:
+//
+//	USED STORAGE:   78281147   0.15% of 53687091200
+//	In ROOT:        78000000 in  12 file(s) and 3 folder(s)
+//	In INBOX:              0 in   0 file(s) and 0 folder(s)
+//	In RUBBISH:       281147 in   1 file(s) and 0 folder(s)
+//	Total size taken up by file versions:   1024
+func parseDF(output string) (*Space, error) {
+	var sp Space
+	found := false
+	scanner := bufio.NewScanner(strings.NewReader(output))
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
-		if strings.Contains(line, "USED STORAGE") {
-			// Regex explicada:
-			// USED STORAGE: -> Texto literal
-			// \s*(\d+)      -> Espacios y el primer numero (USADO)
-			// .*of          -> Cualquier cosa hasta llegar a "of"
-			// \s*(\d+)      -> Espacios y el segundo numero (TOTAL)
-			re := regexp.MustCompile(`USED STORAGE:\s*(\d+).*of\s*(\d+)`)
-			matches := re.FindStringSubmatch(line)
-
-			if len(matches) >= 3 {
-				used, _ := strconv.ParseInt(matches[1], 10, 64)
-				total, _ := strconv.ParseInt(matches[2], 10, 64)
-				return used, total, nil
+		if m := dfUsedRe.FindStringSubmatch(line); m != nil {
+			sp.Used, _ = strconv.ParseInt(m[1], 10, 64)
+			sp.Total, _ = strconv.ParseInt(m[2], 10, 64)
+			found = true
+		} else if m := dfSectionRe.FindStringSubmatch(line); m != nil {
+			n, _ := strconv.ParseInt(m[2], 10, 64)
+			switch strings.ToUpper(m[1]) {
+			case "ROOT", "CLOUD DRIVE":
+				sp.CloudDrive = n
+			case "INBOX", "VAULT":
+				sp.Inbox = n
+			case "RUBBISH", "RUBBISH BIN":
+				sp.Rubbish = n
 			}
+		} else if m := dfVersionsRe.FindStringSubmatch(line); m != nil {
+			sp.Versions, _ = strconv.ParseInt(m[1], 10, 64)
 		}
 	}
-
-	return 0, 0, fmt.Errorf("no se encontraron datos de espacio")
+	if !found {
+		return nil, fmt.Errorf("no se encontraron datos de espacio")
+	}
+	return &sp, nil
 }
 
 func Logout() {
-	exec.Command("mega-logout").Run()
+	megaCommand("mega-logout").Run()
 }
 
 // IsLoggedIn comprueba si la sesión está activa
 func IsLoggedIn() bool {
-	err := exec.Command("mega-whoami").Run()
-	return err == nil
+	return CurrentAccount() != ""
+}
+
+var accountRe = regexp.MustCompile(`[^\s:]+@[^\s]+`)
+
+// CurrentAccount devuelve el email de la sesión de MEGAcmd ("" si no hay)
+func CurrentAccount() string {
+	out, err := megaCommand("mega-whoami").Output()
+	if err != nil {
+		return ""
+	}
+	return accountRe.FindString(string(out))
 }
 
 func GetMountPath() string {
 	h, _ := os.UserHomeDir()
 	return filepath.Join(h, "Nubes", "Mega")
 }
+
+// StopWebDAV deja de servir una ruta de MEGA por WebDAV
+func StopWebDAV(path string) error {
+	if path == "" {
+		path = "/"
+	}
+	if out, err := megaCommand("mega-webdav", "-d", path).CombinedOutput(); err != nil {
+		return fmt.Errorf("error webdav: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// HTTPClient se usa para comprobar que el puente WebDAV responde
+var HTTPClient = &http.Client{Timeout: 5 * time.Second}
+
+// CheckWebDAV confirma que el servidor WebDAV local responde a un PROPFIND
+// de la raíz. Reintenta unos segundos porque MEGAcmd tarda en levantarlo.
+func CheckWebDAV(webdavURL string) error {
+	var lastErr error
+	for attempt := 0; attempt < 5; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Second)
+		}
+		req, err := http.NewRequest("PROPFIND", webdavURL, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Depth", "0")
+		resp, err := HTTPClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusMultiStatus {
+			return nil
+		}
+		lastErr = fmt.Errorf("respondio %s", resp.Status)
+	}
+	return fmt.Errorf("el puente WebDAV de Mega (%s) no responde: %v", webdavURL, lastErr)
+}
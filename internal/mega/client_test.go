@@ -0,0 +1,83 @@
+package mega
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Salidas de mega-login cuando la cuenta tiene 2FA y no se da el código
+var login2FAOutputs = []string{
+	"Enter the code generated by your authentication app: \n[err: 10:42:17] Login failed: unexpected end of input\n",
+	"[API:err: 10:42:17] Login failed: API_EMFAREQUIRED\n",
+	"[err: 10:42:17] Login failed: Multi-factor authentication required (-26)\n",
+	"Login failed: error code -26\n",
+}
+
+// fakeLogin instala un mega-login que imprime out, guarda sus argumentos y
+// stdin y sale con exit, junto a un servidor falso sin sesión abierta
+func fakeLogin(t *testing.T, out string, exit int) string {
+	t.Helper()
+	dir := fakeMegaCmd(t, "")
+	t.Cleanup(Daemon.Stop) // Antes de restaurar BinDir
+	outFile := filepath.Join(dir, "login.out")
+	if err := os.WriteFile(outFile, []byte(out), 0644); err != nil {
+		t.Fatal(err)
+	}
+	script := fmt.Sprintf("#!/bin/sh\necho \"$@\" > %q\ncat > %q\ncat %q\nexit %d\n",
+		filepath.Join(dir, "login.args"), filepath.Join(dir, "login.stdin"), outFile, exit)
+	if err := os.WriteFile(filepath.Join(dir, "mega-login"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLoginNeeds2FA(t *testing.T) {
+	for i, out := range login2FAOutputs {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			fakeLogin(t, out, 1)
+			if err := Login("ana@ejemplo.com", "Sup3rSecreto", ""); err != ErrNeeds2FA {
+				t.Errorf("salida %q: esperaba ErrNeeds2FA, obtuve %v", out, err)
+			}
+		})
+	}
+}
+
+func TestLoginFailureHidesPassword(t *testing.T) {
+	const pass = "Sup3rSecreto"
+	dir := fakeLogin(t, "[err: 10:42:17] Login failed: invalid email or password ("+pass+")\n", 1)
+
+	err := Login("ana@ejemplo.com", pass, "123456")
+	if err == nil || err == ErrNeeds2FA {
+		t.Fatalf("esperaba un error de login, obtuve %v", err)
+	}
+	if strings.Contains(err.Error(), pass) {
+		t.Fatalf("el error muestra la contraseña: %v", err)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(dir, "login.args"))
+	if strings.Contains(string(args), pass) {
+		t.Fatalf("la contraseña aparece en los argumentos: %s", args)
+	}
+	if !strings.Contains(string(args), "--auth-code=123456") {
+		t.Fatalf("falta el código 2FA en los argumentos: %s", args)
+	}
+	stdin, _ := os.ReadFile(filepath.Join(dir, "login.stdin"))
+	if string(stdin) != pass+"\n" {
+		t.Fatalf("stdin = %q, esperaba la contraseña", stdin)
+	}
+}
+
+func TestNeed2FAIgnoresOtherErrors(t *testing.T) {
+	for _, out := range []string{
+		"[err: 10:42:17] Login failed: invalid email or password\n",
+		"[err: 10:42:17] Login failed: Too many requests (-3)\n",
+		"[err: 10:42:17] Login failed: Not found (-9) at 2024-10-26\n",
+	} {
+		if need2FARe.MatchString(out) {
+			t.Errorf("%q no debía tomarse como petición de 2FA", out)
+		}
+	}
+}
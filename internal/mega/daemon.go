@@ -0,0 +1,231 @@
+package mega
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/settings"
+)
+
+// Programas de MEGAcmd. Se pueden cambiar para usar otra instalación o,
+// en pruebas, scripts falsos que imiten su comportamiento.
+var (
+	BinDir       = ""                // Directorio de los mega-*; "" = buscar en el PATH
+	ServerBinary = "mega-cmd-server" // Servidor en segundo plano
+	ProbeBinary  = "mega-version"    // Comando ligero para saber si el servidor responde
+)
+
+// Tiempos del supervisor
+var (
+	ReadyTimeout  = 15 * time.Second // Máximo para que el servidor responda tras arrancarlo
+	ProbeTimeout  = 5 * time.Second  // Máximo por cada sondeo
+	WatchInterval = 30 * time.Second // Cada cuánto se comprueba que sigue vivo
+)
+
+func binary(name string) string {
+	if BinDir != "" {
+		return filepath.Join(BinDir, name)
+	}
+	return name
+}
+
+// megaCommand prepara un comando de MEGAcmd respetando BinDir
+func megaCommand(name string, args ...string) *exec.Cmd {
+	return exec.Command(binary(name), args...)
+}
+
+// Health es el estado del servidor de MEGAcmd que se muestra en la tarjeta
+type Health struct {
+	Running   bool
+	Since     time.Time // Desde cuándo responde
+	Restarts  int       // Reinicios tras una caída
+	LastError string
+}
+
+// Supervisor arranca mega-cmd-server, espera a que responda y lo vuelve a
+// levantar si se cae
+type Supervisor struct {
+	startMu  sync.Mutex // Un solo arranque a la vez
+	mu       sync.Mutex // Protege health, watching y stop
+	health   Health
+	watching bool
+	stop     chan struct{} // Se cierra para terminar Watch
+	stopped  chan struct{} // Se cierra cuando Watch ha terminado
+}
+
+// Daemon es el supervisor que usa la app
+var Daemon = &Supervisor{}
+
+// logPath es donde va la salida del servidor: al ir desacoplado de la app
+// no puede escribir en una tubería nuestra
+func logPath() string {
+	return filepath.Join(settings.ConfigDir(), "mega-cmd-server.log")
+}
+
+// probe indica si el servidor responde
+func probe() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), ProbeTimeout)
+	defer cancel()
+	return exec.CommandContext(ctx, binary(ProbeBinary)).Run() == nil
+}
+
+// Health devuelve el último estado conocido sin esperar a ningún arranque
+func (s *Supervisor) Health() Health {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.health
+}
+
+// Check sondea el servidor sin arrancarlo y devuelve el estado actualizado
+func (s *Supervisor) Check() Health {
+	if probe() {
+		s.setRunning(true, "")
+	} else {
+		s.mu.Lock()
+		s.health.Running = false
+		s.mu.Unlock()
+	}
+	return s.Health()
+}
+
+func (s *Supervisor) setRunning(running bool, lastError string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if running && !s.health.Running {
+		s.health.Since = time.Now()
+	}
+	s.health.Running = running
+	s.health.LastError = lastError
+}
+
+// Ensure comprueba que el servidor responde y, si no, lo arranca y espera
+// a que esté listo
+func (s *Supervisor) Ensure() error {
+	s.startMu.Lock()
+	defer s.startMu.Unlock()
+	if probe() {
+		s.setRunning(true, "")
+		return nil
+	}
+	s.setRunning(false, "")
+	if err := start(); err != nil {
+		s.setRunning(false, err.Error())
+		return err
+	}
+	s.setRunning(true, "")
+	return nil
+}
+
+// start lanza el servidor desacoplado y sondea hasta ReadyTimeout
+func start() error {
+	logFile, err := os.OpenFile(logPath(), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("error abriendo log de MEGAcmd: %v", err)
+	}
+	defer logFile.Close()
+
+	cmd := megaCommand(ServerBinary)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	// Setsid crea una sesión nueva: el servidor sigue vivo al cerrar la app
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("error iniciando servidor mega: %v", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	deadline := time.Now().Add(ReadyTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case err := <-exited:
+			return fmt.Errorf("mega-cmd-server termino al arrancar (%v): %s", err, serverOutput())
+		default:
+		}
+		if probe() {
+			return nil
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	cmd.Process.Kill()
+	return fmt.Errorf("mega-cmd-server no respondio en %s: %s", ReadyTimeout, serverOutput())
+}
+
+// serverOutput devuelve las últimas líneas del log del servidor
+func serverOutput() string {
+	f, err := os.Open(logPath())
+	if err != nil {
+		return "(sin salida)"
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil && info.Size() > 2048 {
+		f.Seek(-2048, io.SeekEnd)
+	}
+	data, _ := io.ReadAll(f)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) > 5 {
+		lines = lines[len(lines)-5:]
+	}
+	out := strings.TrimSpace(strings.Join(lines, "\n"))
+	if out == "" {
+		return "(sin salida)"
+	}
+	return out
+}
+
+// Watch comprueba periódicamente que el servidor sigue respondiendo y lo
+// reinicia si se ha caído. Solo se lanza una vez.
+func (s *Supervisor) Watch() {
+	s.mu.Lock()
+	if s.watching {
+		s.mu.Unlock()
+		return
+	}
+	s.watching = true
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	s.stop, s.stopped = stop, stopped
+	s.mu.Unlock()
+
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(WatchInterval):
+			}
+			if probe() {
+				s.setRunning(true, "")
+				continue
+			}
+			s.setRunning(false, "mega-cmd-server no responde")
+			if s.Ensure() == nil {
+				s.mu.Lock()
+				s.health.Restarts++
+				s.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// Stop termina la vigilancia de Watch (el servidor sigue en marcha)
+func (s *Supervisor) Stop() {
+	s.mu.Lock()
+	if !s.watching {
+		s.mu.Unlock()
+		return
+	}
+	s.watching = false
+	close(s.stop)
+	stopped := s.stopped
+	s.mu.Unlock()
+	<-stopped
+}
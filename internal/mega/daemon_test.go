@@ -0,0 +1,143 @@
+package mega
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeMegaCmd instala en un directorio temporal un mega-cmd-server y un
+// mega-version falsos. El servidor apunta su PID en server.pid y se queda
+// dormido; el sondeo responde mientras ese proceso siga vivo. server es el
+// cuerpo del servidor ("" = el normal).
+func fakeMegaCmd(t *testing.T, server string) string {
+	t.Helper()
+	dir := t.TempDir()
+	pidFile := filepath.Join(dir, "server.pid")
+	if server == "" {
+		server = fmt.Sprintf("echo arrancado >> %q\necho $$ > %q\nexec sleep 600", filepath.Join(dir, "starts"), pidFile)
+	}
+	scripts := map[string]string{
+		ServerBinary: server,
+		ProbeBinary:  fmt.Sprintf("[ -f %q ] && kill -0 \"$(cat %q)\" 2>/dev/null", pidFile, pidFile),
+	}
+	for name, body := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	oldDir, oldReady, oldProbe, oldWatch := BinDir, ReadyTimeout, ProbeTimeout, WatchInterval
+	BinDir, ReadyTimeout, ProbeTimeout, WatchInterval = dir, 5*time.Second, time.Second, 50*time.Millisecond
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Cleanup(func() {
+		// El servidor va en su propia sesión: hay que pararlo a mano
+		if pid, err := serverPID(dir); err == nil {
+			syscall.Kill(pid, syscall.SIGKILL)
+		}
+		BinDir, ReadyTimeout, ProbeTimeout, WatchInterval = oldDir, oldReady, oldProbe, oldWatch
+	})
+	return dir
+}
+
+func serverPID(dir string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "server.pid"))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+func serverStarts(dir string) int {
+	data, _ := os.ReadFile(filepath.Join(dir, "starts"))
+	return strings.Count(string(data), "arrancado")
+}
+
+func TestEnsureStartsServerOnce(t *testing.T) {
+	dir := fakeMegaCmd(t, "")
+	s := &Supervisor{}
+
+	if h := s.Check(); h.Running {
+		t.Fatal("Check no debía ver el servidor antes de arrancarlo")
+	}
+	if err := s.Ensure(); err != nil {
+		t.Fatal(err)
+	}
+	h := s.Health()
+	if !h.Running || h.Since.IsZero() || h.LastError != "" {
+		t.Fatalf("estado tras arrancar: %+v", h)
+	}
+	// Si ya responde no se lanza otro
+	if err := s.Ensure(); err != nil {
+		t.Fatal(err)
+	}
+	if n := serverStarts(dir); n != 1 {
+		t.Fatalf("el servidor se arrancó %d veces, esperaba 1", n)
+	}
+}
+
+func TestEnsureServerExits(t *testing.T) {
+	fakeMegaCmd(t, "echo 'puerto ocupado' >&2\nexit 3")
+	s := &Supervisor{}
+
+	err := s.Ensure()
+	if err == nil || !strings.Contains(err.Error(), "puerto ocupado") {
+		t.Fatalf("esperaba el error del servidor con su salida, obtuve %v", err)
+	}
+	if h := s.Health(); h.Running || h.LastError == "" {
+		t.Fatalf("estado tras fallar: %+v", h)
+	}
+}
+
+func TestEnsureServerNeverReady(t *testing.T) {
+	fakeMegaCmd(t, "exec sleep 600")
+	ReadyTimeout = 500 * time.Millisecond
+	s := &Supervisor{}
+
+	err := s.Ensure()
+	if err == nil || !strings.Contains(err.Error(), "no respondio") {
+		t.Fatalf("esperaba error de tiempo agotado, obtuve %v", err)
+	}
+}
+
+func TestWatchRestartsCrashedServer(t *testing.T) {
+	dir := fakeMegaCmd(t, "")
+	s := &Supervisor{}
+	if err := s.Ensure(); err != nil {
+		t.Fatal(err)
+	}
+	s.Watch()
+	defer s.Stop()
+
+	pid, err := serverPID(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Simula una caída del servidor
+	if err := syscall.Kill(pid, syscall.SIGKILL); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		h := s.Health()
+		if h.Restarts == 1 && h.Running {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("el supervisor no reinició el servidor: %+v", h)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if n := serverStarts(dir); n != 2 {
+		t.Fatalf("el servidor se arrancó %d veces, esperaba 2", n)
+	}
+	if newPID, _ := serverPID(dir); newPID == pid {
+		t.Fatal("el servidor reiniciado tiene el mismo PID")
+	}
+}
@@ -0,0 +1,234 @@
+package mega
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SyncPair es una pareja carpeta local <-> carpeta de MEGA de `mega-sync`
+type SyncPair struct {
+	ID         string
+	LocalPath  string
+	RemotePath string
+	RunState   string // Running, Paused, Disabled...
+	Status     string // Synced, Syncing, Pending...
+	Error      string
+}
+
+// Export es un enlace público creado con `mega-export`
+type Export struct {
+	Path string
+	Link string
+}
+
+// Transfer es una transferencia en curso de `mega-transfers`
+type Transfer struct {
+	Tag         string
+	Type        string // Subida o descarga
+	Source      string
+	Destination string
+	Progress    string
+	State       string
+}
+
+// run ejecuta un comando de MEGAcmd y devuelve su salida o un error con ella
+func run(name string, args ...string) (string, error) {
+	out, err := megaCommand(name, args...).CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("%s: %s", name, msg)
+	}
+	return string(out), nil
+}
+
+// parseTable lee una tabla de MEGAcmd pedida con --col-separator=<tab>:
+// la primera línea son los nombres de columna
+func parseTable(out string) []map[string]string {
+	var rows []map[string]string
+	var header []string
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		cols := strings.Split(line, "\t")
+		if header == nil {
+			for _, c := range cols {
+				header = append(header, strings.ToUpper(strings.TrimSpace(c)))
+			}
+			continue
+		}
+		row := map[string]string{}
+		for i, c := range cols {
+			if i < len(header) {
+				row[header[i]] = strings.TrimSpace(c)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// --- SINCRONIZACION ---
+
+// ListSyncs devuelve las parejas configuradas con su estado
+func ListSyncs() ([]SyncPair, error) {
+	out, err := run("mega-sync", "--col-separator=\t", "--output-cols=ID,LOCALPATH,REMOTEPATH,RUN_STATE,STATUS,ERROR")
+	if err != nil {
+		return nil, err
+	}
+	var pairs []SyncPair
+	for _, r := range parseTable(out) {
+		if r["ID"] == "" {
+			continue
+		}
+		pairs = append(pairs, SyncPair{
+			ID:         r["ID"],
+			LocalPath:  r["LOCALPATH"],
+			RemotePath: r["REMOTEPATH"],
+			RunState:   r["RUN_STATE"],
+			Status:     r["STATUS"],
+			Error:      r["ERROR"],
+		})
+	}
+	return pairs, nil
+}
+
+// AddSync empieza a sincronizar una carpeta local con una de MEGA
+func AddSync(localPath, remotePath string) error {
+	_, err := run("mega-sync", localPath, remotePath)
+	return err
+}
+
+// PauseSync detiene una sincronización sin borrarla
+func PauseSync(id string) error {
+	_, err := run("mega-sync", "--pause", id)
+	return err
+}
+
+// ResumeSync reanuda una sincronización pausada
+func ResumeSync(id string) error {
+	_, err := run("mega-sync", "--enable", id)
+	return err
+}
+
+// RemoveSync deja de sincronizar (los archivos no se borran)
+func RemoveSync(id string) error {
+	_, err := run("mega-sync", "--delete", id)
+	return err
+}
+
+// --- ENLACES PUBLICOS ---
+
+var (
+	exportLinkRe = regexp.MustCompile(`https://mega\.nz/\S+[^\s).]`)
+	exportLineRe = regexp.MustCompile(`^(.+?) \(.*(https://mega\.nz/\S+[^\s).])`)
+)
+
+// ListExports devuelve las rutas con enlace público
+func ListExports() ([]Export, error) {
+	out, err := run("mega-export")
+	if err != nil {
+		return nil, err
+	}
+	var exports []Export
+	for _, line := range strings.Split(out, "\n") {
+		if m := exportLineRe.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			exports = append(exports, Export{Path: m[1], Link: m[2]})
+		}
+	}
+	return exports, nil
+}
+
+// CreateExport crea (o devuelve) el enlace público de una ruta de MEGA
+func CreateExport(path string) (string, error) {
+	out, err := run("mega-export", "-a", "-f", path)
+	if err != nil {
+		return "", err
+	}
+	if link := exportLinkRe.FindString(out); link != "" {
+		return link, nil
+	}
+	return "", fmt.Errorf("mega-export no devolvio ningun enlace: %s", strings.TrimSpace(out))
+}
+
+// RemoveExport elimina el enlace público de una ruta
+func RemoveExport(path string) error {
+	_, err := run("mega-export", "-d", path)
+	return err
+}
+
+// --- TRANSFERENCIAS ---
+
+// ListTransfers devuelve las transferencias activas
+func ListTransfers() ([]Transfer, error) {
+	out, err := run("mega-transfers", "--col-separator=\t", "--output-cols=TYPE,TAG,SOURCEPATH,DESTINYPATH,PROGRESS,STATE")
+	if err != nil {
+		return nil, err
+	}
+	var transfers []Transfer
+	for _, r := range parseTable(out) {
+		if r["TAG"] == "" {
+			continue
+		}
+		transfers = append(transfers, Transfer{
+			Tag:         r["TAG"],
+			Type:        r["TYPE"],
+			Source:      r["SOURCEPATH"],
+			Destination: r["DESTINYPATH"],
+			Progress:    r["PROGRESS"],
+			State:       r["STATE"],
+		})
+	}
+	return transfers, nil
+}
+
+// CancelTransfer cancela una transferencia por su etiqueta
+func CancelTransfer(tag string) error {
+	_, err := run("mega-transfers", "-c", tag)
+	return err
+}
+
+// --- LIMITE DE VELOCIDAD ---
+
+// SetSpeedLimit aplica un límite con el formato de BwLimit de rclone:
+// "" u "off" = sin límite, "2M" = subida y bajada, "1M:4M" = subida:bajada
+func SetSpeedLimit(bwlimit string) error {
+	up, down, err := splitBwLimit(bwlimit)
+	if err != nil {
+		return err
+	}
+	if _, err := run("mega-speedlimit", "-u", up); err != nil {
+		return err
+	}
+	_, err = run("mega-speedlimit", "-d", down)
+	return err
+}
+
+// ValidateSpeedLimit comprueba que MEGAcmd entiende el límite antes de guardarlo
+func ValidateSpeedLimit(bwlimit string) error {
+	_, _, err := splitBwLimit(bwlimit)
+	return err
+}
+
+var speedRe = regexp.MustCompile(`^\d+(\.\d+)?[KMGkmg]?$`)
+
+func splitBwLimit(bwlimit string) (up, down string, err error) {
+	bwlimit = strings.TrimSpace(bwlimit)
+	if bwlimit == "" || strings.EqualFold(bwlimit, "off") {
+		return "0", "0", nil
+	}
+	up, down = bwlimit, bwlimit
+	if i := strings.Index(bwlimit, ":"); i >= 0 {
+		up, down = bwlimit[:i], bwlimit[i+1:]
+	}
+	for _, v := range []string{up, down} {
+		if !speedRe.MatchString(v) {
+			return "", "", fmt.Errorf("limite no admitido por MEGAcmd: %q (usa por ejemplo 2M o 1M:4M)", bwlimit)
+		}
+	}
+	return strings.ToUpper(up), strings.ToUpper(down), nil
+}
@@ -0,0 +1,107 @@
+package mega
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseTable(t *testing.T) {
+	out := "ID\tLOCALPATH\tREMOTEPATH\tRUN_STATE\tSTATUS\tERROR\n" +
+		"ABC123\t/home/ana/Fotos\t/Fotos\tRunning\tSynced\tNO\n" +
+		"\n" +
+		"DEF456\t/home/ana/Mis Documentos\t/Documentos\tDisabled\tPending\n"
+	got := parseTable(out)
+	want := []map[string]string{
+		{"ID": "ABC123", "LOCALPATH": "/home/ana/Fotos", "REMOTEPATH": "/Fotos", "RUN_STATE": "Running", "STATUS": "Synced", "ERROR": "NO"},
+		{"ID": "DEF456", "LOCALPATH": "/home/ana/Mis Documentos", "REMOTEPATH": "/Documentos", "RUN_STATE": "Disabled", "STATUS": "Pending"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseTable =\n%v\nesperaba\n%v", got, want)
+	}
+
+	if rows := parseTable("TYPE\tTAG\n"); rows != nil {
+		t.Fatalf("una tabla sin filas debía dar nil, obtuve %v", rows)
+	}
+}
+
+func TestSplitBwLimit(t *testing.T) {
+	tests := []struct {
+		in       string
+		up, down string
+		ok       bool
+	}{
+		{"", "0", "0", true},
+		{"off", "0", "0", true},
+		{" OFF ", "0", "0", true},
+		{"2M", "2M", "2M", true},
+		{"512k", "512K", "512K", true},
+		{"1M:4M", "1M", "4M", true},
+		{"1.5m:0", "1.5M", "0", true},
+		{"100", "100", "100", true},
+		{"2MB", "", "", false},
+		{"1M:", "", "", false},
+		{"08:00,512k", "", "", false},
+		{"rapido", "", "", false},
+	}
+	for _, tt := range tests {
+		up, down, err := splitBwLimit(tt.in)
+		if (err == nil) != tt.ok || up != tt.up || down != tt.down {
+			t.Errorf("splitBwLimit(%q) = %q, %q, %v", tt.in, up, down, err)
+		}
+		if (ValidateSpeedLimit(tt.in) == nil) != tt.ok {
+			t.Errorf("ValidateSpeedLimit(%q) no coincide con splitBwLimit", tt.in)
+		}
+	}
+}
+
+// fakeExport instala un mega-export que imprime out
+func fakeExport(t *testing.T, out string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "out"), []byte(out), 0644); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\ncat " + filepath.Join(dir, "out") + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "mega-export"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	old := BinDir
+	BinDir = dir
+	t.Cleanup(func() { BinDir = old })
+}
+
+func TestListExports(t *testing.T) {
+	fakeExport(t, `/Documentos (folder, shared as exported permanent folder link: https://mega.nz/folder/AbCdEf12#Kk9-xYz_w)
+/Fotos/playa 2024.jpg (file, shared as exported permanent file link: https://mega.nz/file/QwErTy34#Zz8_aBc-d).
+/Sin enlace
+`)
+	got, err := ListExports()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Export{
+		{Path: "/Documentos", Link: "https://mega.nz/folder/AbCdEf12#Kk9-xYz_w"},
+		{Path: "/Fotos/playa 2024.jpg", Link: "https://mega.nz/file/QwErTy34#Zz8_aBc-d"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ListExports =\n%v\nesperaba\n%v", got, want)
+	}
+}
+
+func TestCreateExport(t *testing.T) {
+	fakeExport(t, "Exported /Documentos: https://mega.nz/folder/AbCdEf12#Kk9-xYz_w.\n")
+	link, err := CreateExport("/Documentos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link != "https://mega.nz/folder/AbCdEf12#Kk9-xYz_w" {
+		t.Fatalf("CreateExport = %q", link)
+	}
+
+	fakeExport(t, "Nothing to export\n")
+	if _, err := CreateExport("/Documentos"); err == nil {
+		t.Fatal("CreateExport debía fallar sin enlace en la salida")
+	}
+}
@@ -0,0 +1,238 @@
+package mega
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// proLevels traduce el "Pro level" numérico de MEGA a su nombre comercial
+var proLevels = map[int]string{
+	0:   "Gratuita",
+	1:   "PRO I",
+	2:   "PRO II",
+	3:   "PRO III",
+	4:   "PRO Lite",
+	100: "Business",
+	101: "Pro Flexi",
+}
+
+var proLevelRe = regexp.MustCompile(`(?i)pro level:\s*(\d+)`)
+
+// AccountLevel devuelve el tipo de cuenta según `mega-whoami -l`
+func AccountLevel() (string, error) {
+	out, err := run("mega-whoami", "-l")
+	if err != nil {
+		return "", err
+	}
+	return parseProLevel(out), nil
+}
+
+func parseProLevel(out string) string {
+	m := proLevelRe.FindStringSubmatch(out)
+	if m == nil {
+		return ""
+	}
+	n, _ := strconv.Atoi(m[1])
+	if name, ok := proLevels[n]; ok {
+		return name
+	}
+	return "PRO " + m[1]
+}
+
+// TransferQuota indica si MEGA ha cortado las descargas por superar la
+// cuota de transferencia y, si se sabe, cuándo se restablece
+type TransferQuota struct {
+	Exceeded bool
+	ResetAt  time.Time // Cero si MEGA no dijo cuánto esperar
+	Line     string    // Línea del log que lo indica
+}
+
+// overQuotaWindow es lo que suponemos que dura el corte cuando el aviso no
+// trae tiempo de espera: MEGA repone la cuota gratuita en unas horas
+const overQuotaWindow = 6 * time.Hour
+
+var (
+	// Las cuentas sin espacio también dan "overquota", pero eso no corta descargas
+	overQuotaRe    = regexp.MustCompile(`(?i)(transfer\s*(over\s*)?quota|bandwidth\s*(over\s*)?quota|transfer.*over\s*quota|EOVERQUOTA|509 Bandwidth Limit Exceeded)`)
+	storageQuotaRe = regexp.MustCompile(`(?i)storage`)
+	waitSecondsRe  = regexp.MustCompile(`(?i)(\d+)\s*(?:s|secs?|seconds?)\b`)
+	waitClockRe    = regexp.MustCompile(`\b(\d{1,2}):(\d{2}):(\d{2})\b`)
+	logTimeRe      = regexp.MustCompile(`^\[?(\d{4}[/-]\d{2}[/-]\d{2}[ _T]\d{2}[:-]\d{2}[:-]\d{2})`)
+)
+
+// CheckTransferQuota busca el último aviso de cuota de transferencia en el
+// log de mega-cmd-server y en los logs que se pasen (p. ej. el del montaje)
+func CheckTransferQuota(logPaths ...string) TransferQuota {
+	var last TransferQuota
+	var lastAt time.Time
+	for _, path := range append([]string{logPath()}, logPaths...) {
+		q, at := transferQuotaIn(path)
+		if q.Exceeded && at.After(lastAt) {
+			last, lastAt = q, at
+		}
+	}
+	if !last.Exceeded {
+		return last
+	}
+	now := time.Now()
+	if (!last.ResetAt.IsZero() && now.After(last.ResetAt)) ||
+		(last.ResetAt.IsZero() && now.Sub(lastAt) > overQuotaWindow) {
+		return TransferQuota{} // Ya se ha restablecido
+	}
+	return last
+}
+
+// quotaTail es cuánto del final de cada log se revisa
+const quotaTail = 64 * 1024
+
+// transferQuotaIn devuelve el último aviso de un log y cuándo se escribió
+func transferQuotaIn(path string) (TransferQuota, time.Time) {
+	var q TransferQuota
+	f, err := os.Open(path)
+	if err != nil {
+		return q, time.Time{}
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return q, time.Time{}
+	}
+	var offset int64
+	if info.Size() > quotaTail {
+		offset, _ = f.Seek(-quotaTail, io.SeekEnd)
+	}
+	data, _ := io.ReadAll(f)
+	if offset > 0 {
+		// La primera línea está cortada: su posición cambiaría en cada lectura
+		if k := bytes.IndexByte(data, '\n'); k >= 0 {
+			offset += int64(k + 1)
+			data = data[k+1:]
+		}
+	}
+
+	var at time.Time
+	window := map[int64]string{} // Avisos sin fecha presentes ahora, por posición
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		lineOffset := offset
+		offset += int64(len(line))
+		if !overQuotaRe.MatchString(line) || storageQuotaRe.MatchString(line) {
+			continue
+		}
+		q = TransferQuota{Exceeded: true, Line: strings.TrimSpace(line)}
+		if t, ok := lineTime(line); ok {
+			at = t
+		} else {
+			// Sin fecha no sirve la del archivo: cualquier escritura posterior
+			// haría pasar un aviso viejo por reciente
+			window[lineOffset] = q.Line
+			at = firstSeen(path, lineOffset, q.Line)
+		}
+		if wait := waitDuration(line); wait > 0 {
+			q.ResetAt = at.Add(wait)
+		}
+	}
+	forgetSeen(path, window)
+	return q, at
+}
+
+// seenLine identifica un aviso sin fecha por su posición en el log
+type seenLine struct {
+	offset int64
+	line   string
+}
+
+var (
+	seenMu    sync.Mutex
+	seenLines = map[string]map[seenLine]time.Time{} // Por log
+)
+
+// firstSeen devuelve cuándo se vio por primera vez el aviso sin fecha que
+// está en esa posición del log. Una repetición es otra línea con otra
+// posición, así que cuenta como aviso nuevo.
+func firstSeen(path string, offset int64, line string) time.Time {
+	seenMu.Lock()
+	defer seenMu.Unlock()
+	lines := seenLines[path]
+	if lines == nil {
+		lines = map[seenLine]time.Time{}
+		seenLines[path] = lines
+	}
+	key := seenLine{offset, line}
+	t, ok := lines[key]
+	if !ok {
+		t = time.Now()
+		lines[key] = t
+	}
+	return t
+}
+
+// forgetSeen olvida los avisos que ya no están en la parte revisada del log
+// (han quedado atrás o el log se ha vaciado)
+func forgetSeen(path string, window map[int64]string) {
+	seenMu.Lock()
+	defer seenMu.Unlock()
+	for key := range seenLines[path] {
+		if window[key.offset] != key.line {
+			delete(seenLines[path], key)
+		}
+	}
+	if len(seenLines[path]) == 0 {
+		delete(seenLines, path)
+	}
+}
+
+// lineTime lee la fecha con la que empiezan las líneas de rclone
+// (2006/01/02 15:04:05) y de MEGAcmd (2006-01-02_15-04-05)
+func lineTime(line string) (time.Time, bool) {
+	m := logTimeRe.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return time.Time{}, false
+	}
+	s := strings.NewReplacer("/", "-", "_", " ", "T", " ").Replace(m[1])
+	if len(s) == 19 {
+		s = s[:13] + ":" + s[14:16] + ":" + s[17:]
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", s, time.Local)
+	return t, err == nil
+}
+
+// waitDuration extrae la espera que indica el aviso ("wait 3600 seconds"
+// o "01:00:00"); 0 si no la hay
+func waitDuration(line string) time.Duration {
+	// Quitamos la fecha inicial para no confundir su hora con una espera
+	if m := logTimeRe.FindString(strings.TrimSpace(line)); m != "" {
+		line = strings.TrimSpace(line)[len(m):]
+	}
+	if m := waitSecondsRe.FindStringSubmatch(line); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return time.Duration(n) * time.Second
+	}
+	if m := waitClockRe.FindStringSubmatch(line); m != nil {
+		h, _ := strconv.Atoi(m[1])
+		min, _ := strconv.Atoi(m[2])
+		sec, _ := strconv.Atoi(m[3])
+		return time.Duration(h)*time.Hour + time.Duration(min)*time.Minute + time.Duration(sec)*time.Second
+	}
+	return 0
+}
+
+// Message describe el corte para mostrarlo al usuario
+func (q TransferQuota) Message() string {
+	if !q.Exceeded {
+		return ""
+	}
+	if q.ResetAt.IsZero() {
+		return "Cuota de transferencia de MEGA agotada: se restablece en unas horas"
+	}
+	when := q.ResetAt.Format("15:04")
+	if q.ResetAt.YearDay() != time.Now().YearDay() {
+		when = q.ResetAt.Format("02/01 15:04")
+	}
+	return "Cuota de transferencia de MEGA agotada: se restablece a las " + when
+}
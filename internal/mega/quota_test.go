@@ -0,0 +1,107 @@
+package mega
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeLog(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransferQuotaTimestamped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mount.log")
+	writeLog(t, path, "2024/05/01 10:00:00 ERROR : foto.jpg: Transfer quota exceeded, wait 3600 seconds\n"+
+		"2024/05/01 11:30:00 INFO  : otra cosa\n")
+
+	q, at := transferQuotaIn(path)
+	if !q.Exceeded {
+		t.Fatal("no se detectó el aviso")
+	}
+	want := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	if !at.Equal(want) {
+		t.Fatalf("at = %v, esperaba la fecha de la línea %v", at, want)
+	}
+	if !q.ResetAt.Equal(want.Add(time.Hour)) {
+		t.Fatalf("ResetAt = %v, esperaba %v", q.ResetAt, want.Add(time.Hour))
+	}
+}
+
+func TestTransferQuotaUntimestamped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mega-cmd-server.log")
+	writeLog(t, path, "Transfer over quota\n")
+	// El archivo es antiguo: su fecha no debe contar como la del aviso
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(path, old, old)
+
+	q, first := transferQuotaIn(path)
+	if !q.Exceeded {
+		t.Fatal("no se detectó el aviso")
+	}
+	if time.Since(first) > time.Minute {
+		t.Fatalf("at = %v, esperaba el momento en que se vio", first)
+	}
+
+	// Otras líneas nuevas no rejuvenecen el aviso
+	time.Sleep(10 * time.Millisecond)
+	writeLog(t, path, "algo sin relacion\n")
+	if _, at := transferQuotaIn(path); !at.Equal(first) {
+		t.Fatalf("el aviso cambió de fecha al crecer el log: %v -> %v", first, at)
+	}
+
+	// Una repetición del aviso sí es un corte nuevo
+	writeLog(t, path, "Transfer over quota\n")
+	if _, at := transferQuotaIn(path); !at.After(first) {
+		t.Fatalf("la repetición debía contar como aviso nuevo: %v", at)
+	}
+}
+
+func TestTransferQuotaIgnoresStorage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mount.log")
+	writeLog(t, path, "2024/05/01 10:00:00 ERROR : EOVERQUOTA: storage quota exceeded\n")
+	if q, _ := transferQuotaIn(path); q.Exceeded {
+		t.Fatal("la cuota de almacenamiento no corta las descargas")
+	}
+}
+
+func TestTransferQuotaWindowScrolls(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mega-cmd-server.log")
+	writeLog(t, path, "Transfer over quota\n")
+	_, first := transferQuotaIn(path)
+
+	// El aviso queda fuera de la parte revisada: se olvida
+	time.Sleep(10 * time.Millisecond)
+	writeLog(t, path, strings.Repeat("linea de relleno sin relacion\n", quotaTail/20))
+	if q, _ := transferQuotaIn(path); q.Exceeded {
+		t.Fatal("un aviso fuera de la ventana no debía contar")
+	}
+	seenMu.Lock()
+	n := len(seenLines[path])
+	seenMu.Unlock()
+	if n != 0 {
+		t.Fatalf("quedan %d avisos recordados de un log que ya no los tiene", n)
+	}
+
+	// Un aviso nuevo con el mismo texto no hereda la fecha del viejo
+	writeLog(t, path, "Transfer over quota\n")
+	q, at := transferQuotaIn(path)
+	if !q.Exceeded || !at.After(first) {
+		t.Fatalf("el aviso nuevo debía tener fecha nueva: %v (el viejo %v)", at, first)
+	}
+	// Y sigue con la misma fecha aunque la ventana avance
+	writeLog(t, path, "otra linea\n")
+	if _, again := transferQuotaIn(path); !again.Equal(at) {
+		t.Fatalf("la fecha cambió al avanzar la ventana: %v -> %v", at, again)
+	}
+}
@@ -0,0 +1,183 @@
+package nextcloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HTTPClient es el cliente usado para hablar con el servidor (sustituible
+// para apuntar a un servidor HTTP local de pruebas)
+var HTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// ErrNotSupported indica que el servidor no ofrece Login Flow v2
+// (ownCloud o Nextcloud anterior a la versión 16)
+var ErrNotSupported = errors.New("el servidor no admite Login Flow v2")
+
+// Credentials es el resultado del flujo: una contraseña de aplicación
+type Credentials struct {
+	Server      string `json:"server"`
+	LoginName   string `json:"loginName"`
+	AppPassword string `json:"appPassword"`
+}
+
+// LoginFlow es un inicio de sesión v2 en curso
+type LoginFlow struct {
+	LoginURL string // URL que el usuario abre en el navegador
+	endpoint string
+	token    string
+}
+
+// NormalizeServer admite "nube.ejemplo.com" o la URL completa y la deja sin "/" final
+func NormalizeServer(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("falta la direccion del servidor")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("direccion no valida: %s", raw)
+	}
+	// Si pegan la URL de la web o del WebDAV nos quedamos con la base
+	for _, cut := range []string{"/index.php", "/remote.php", "/apps/"} {
+		if i := strings.Index(u.Path, cut); i >= 0 {
+			u.Path = u.Path[:i]
+		}
+	}
+	u.RawQuery, u.Fragment = "", ""
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
+
+// WebDAVURL construye la URL WebDAV de los archivos del usuario
+func WebDAVURL(server, user string) string {
+	return strings.TrimSuffix(server, "/") + "/remote.php/dav/files/" + url.PathEscape(user) + "/"
+}
+
+// Start inicia el Login Flow v2 en el servidor
+func Start(ctx context.Context, server string) (*LoginFlow, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server+"/index.php/login/v2", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "CloudMount Wizard")
+	resp, err := HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo conectar con %s: %w", server, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		return nil, ErrNotSupported
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("el servidor respondio %s", resp.Status)
+	}
+
+	var body struct {
+		Poll struct {
+			Token    string `json:"token"`
+			Endpoint string `json:"endpoint"`
+		} `json:"poll"`
+		Login string `json:"login"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Login == "" || body.Poll.Token == "" {
+		return nil, ErrNotSupported
+	}
+	return &LoginFlow{LoginURL: body.Login, endpoint: body.Poll.Endpoint, token: body.Poll.Token}, nil
+}
+
+// Wait consulta el servidor hasta que el usuario aprueba el acceso en el
+// navegador, el token caduca (20 minutos) o se cancela el contexto
+func (f *LoginFlow) Wait(ctx context.Context, interval time.Duration) (*Credentials, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		creds, err := f.poll(ctx)
+		if err != nil || creds != nil {
+			return creds, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll devuelve (nil, nil) mientras el usuario no haya terminado
+func (f *LoginFlow) poll(ctx context.Context) (*Credentials, error) {
+	form := url.Values{"token": {f.token}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := HTTPClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// Fallo de red puntual: seguimos esperando
+		return nil, nil
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return nil, nil
+	case http.StatusOK:
+		var creds Credentials
+		data, _ := io.ReadAll(resp.Body)
+		if err := json.Unmarshal(data, &creds); err != nil || creds.AppPassword == "" {
+			return nil, fmt.Errorf("respuesta de inicio de sesion no valida")
+		}
+		return &creds, nil
+	default:
+		return nil, fmt.Errorf("el servidor respondio %s", resp.Status)
+	}
+}
+
+// UserID pregunta al servidor (OCS) el identificador del usuario. El nombre
+// de inicio de sesión puede ser un email o un alias de LDAP, pero la ruta
+// WebDAV usa siempre el identificador interno.
+func UserID(ctx context.Context, server, loginName, appPassword string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(server, "/")+"/ocs/v1.php/cloud/user?format=json", nil)
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(loginName, appPassword)
+	req.Header.Set("OCS-APIRequest", "true")
+	req.Header.Set("User-Agent", "CloudMount Wizard")
+	resp, err := HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("no se pudo conectar con %s: %w", server, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return "", fmt.Errorf("el servidor rechazo el usuario o la contraseña de aplicacion")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("el servidor respondio %s", resp.Status)
+	}
+	var body struct {
+		OCS struct {
+			Data struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		} `json:"ocs"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.OCS.Data.ID == "" {
+		return "", fmt.Errorf("respuesta de usuario no valida")
+	}
+	return body.OCS.Data.ID, nil
+}
@@ -0,0 +1,152 @@
+package nextcloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeNextcloud imita Login Flow v2 y el OCS de usuario de un servidor
+// Nextcloud cuyo nombre de inicio de sesión no coincide con el identificador
+type fakeNextcloud struct {
+	*httptest.Server
+	mu      sync.Mutex
+	polls   int
+	pending int // Consultas que responden 404 antes de conceder el acceso
+}
+
+const (
+	testLogin = "ana@ejemplo.com"
+	testID    = "ana.garcia"
+	testToken = "token-de-sondeo"
+	testPass  = "app-password"
+)
+
+func newFakeNextcloud(t *testing.T) *fakeNextcloud {
+	t.Helper()
+	f := &fakeNextcloud{pending: 2}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /index.php/login/v2", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"poll":  map[string]string{"token": testToken, "endpoint": f.URL + "/index.php/login/v2/poll"},
+			"login": f.URL + "/index.php/login/v2/flow/abc",
+		})
+	})
+	mux.HandleFunc("POST /index.php/login/v2/poll", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("token") != testToken {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		f.mu.Lock()
+		f.polls++
+		wait := f.polls <= f.pending
+		f.mu.Unlock()
+		if wait {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// El servidor devuelve su dirección canónica con "/" final
+		json.NewEncoder(w).Encode(Credentials{Server: f.URL + "/", LoginName: testLogin, AppPassword: testPass})
+	})
+	mux.HandleFunc("GET /ocs/v1.php/cloud/user", func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != testLogin || pass != testPass || r.Header.Get("OCS-APIRequest") != "true" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, `{"ocs":{"meta":{"status":"ok","statuscode":100},"data":{"id":%q,"display-name":"Ana"}}}`, testID)
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func TestLoginFlow(t *testing.T) {
+	srv := newFakeNextcloud(t)
+	ctx := context.Background()
+
+	flow, err := Start(ctx, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flow.LoginURL != srv.URL+"/index.php/login/v2/flow/abc" {
+		t.Fatalf("LoginURL = %q", flow.LoginURL)
+	}
+	creds, err := flow.Wait(ctx, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.LoginName != testLogin || creds.AppPassword != testPass {
+		t.Fatalf("credenciales inesperadas: %+v", creds)
+	}
+	server, err := NormalizeServer(creds.Server)
+	if err != nil || server != srv.URL {
+		t.Fatalf("NormalizeServer(%q) = %q, %v", creds.Server, server, err)
+	}
+
+	id, err := UserID(ctx, server, creds.LoginName, creds.AppPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != testID {
+		t.Fatalf("UserID = %q, esperaba %q", id, testID)
+	}
+	if got, want := WebDAVURL(server, id), srv.URL+"/remote.php/dav/files/ana.garcia/"; got != want {
+		t.Fatalf("WebDAVURL = %q, esperaba %q", got, want)
+	}
+}
+
+func TestUserIDWrongPassword(t *testing.T) {
+	srv := newFakeNextcloud(t)
+	if _, err := UserID(context.Background(), srv.URL, testLogin, "otra"); err == nil {
+		t.Fatal("UserID debía fallar con una contraseña incorrecta")
+	}
+}
+
+func TestNotSupported(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	if _, err := Start(context.Background(), srv.URL); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("esperaba ErrNotSupported, obtuve %v", err)
+	}
+}
+
+func TestStartCanceled(t *testing.T) {
+	// Un servidor que no responde hasta que se cancela la petición
+	block := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-block:
+		}
+	}))
+	defer srv.Close()
+	defer close(block)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err := Start(ctx, srv.URL)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("esperaba context.Canceled, obtuve %v", err)
+	}
+}
+
+func TestWaitCanceled(t *testing.T) {
+	srv := newFakeNextcloud(t)
+	srv.pending = 1 << 30
+	flow, err := Start(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if _, err := flow.Wait(ctx, 10*time.Millisecond); !errors.Is(err, context.Canceled) {
+		t.Fatalf("esperaba context.Canceled, obtuve %v", err)
+	}
+}
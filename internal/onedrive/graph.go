@@ -0,0 +1,162 @@
+package onedrive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HTTPClient es el cliente usado para hablar con Microsoft Graph
+var HTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// GraphURLs es la URL base de Graph según la región del remote (opción
+// "region" de rclone); sustituible para apuntar a un servidor de pruebas
+var GraphURLs = map[string]string{
+	"global": "https://graph.microsoft.com/v1.0",
+	"us":     "https://graph.microsoft.us/v1.0",
+	"de":     "https://graph.microsoft.de/v1.0",
+	"cn":     "https://microsoftgraph.chinacloudapi.cn/v1.0",
+}
+
+// Tipos de unidad que espera la opción drive_type de rclone
+const (
+	TypePersonal        = "personal"
+	TypeBusiness        = "business"
+	TypeDocumentLibrary = "documentLibrary"
+)
+
+// Drive es una unidad accesible con la cuenta: OneDrive personal, OneDrive
+// para la empresa o una biblioteca de documentos de SharePoint
+type Drive struct {
+	ID   string
+	Name string
+	Type string
+	Site string // Sitio de SharePoint ("" si es el OneDrive del usuario)
+}
+
+// Label es el texto que se muestra al usuario
+func (d Drive) Label() string {
+	if d.Site != "" {
+		return d.Site + " / " + d.Name
+	}
+	switch d.Type {
+	case TypePersonal:
+		return "OneDrive personal (" + d.Name + ")"
+	case TypeBusiness:
+		return "OneDrive empresa (" + d.Name + ")"
+	}
+	return d.Name
+}
+
+// Client consulta Graph con el access token del remote
+type Client struct {
+	base  string
+	token string
+}
+
+// NewClient prepara un cliente para la región indicada ("" = global)
+func NewClient(region, accessToken string) *Client {
+	base, ok := GraphURLs[region]
+	if !ok {
+		base = GraphURLs["global"]
+	}
+	return &Client{base: base, token: accessToken}
+}
+
+type graphDrive struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	DriveType string `json:"driveType"`
+}
+
+func (c *Client) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.base+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	resp, err := HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("no se pudo conectar con Microsoft Graph: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var gErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&gErr) == nil && gErr.Error.Message != "" {
+			return fmt.Errorf("Graph %s: %s", path, gErr.Error.Message)
+		}
+		return fmt.Errorf("Graph %s: %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// ListDrives devuelve la unidad del usuario, las demás unidades a las que
+// tiene acceso y, en cuentas de empresa, las bibliotecas de cada sitio
+func (c *Client) ListDrives(ctx context.Context) ([]Drive, error) {
+	var me graphDrive
+	if err := c.get(ctx, "/me/drive", &me); err != nil {
+		return nil, err
+	}
+	drives := []Drive{{ID: me.ID, Name: me.Name, Type: me.DriveType}}
+	seen := map[string]bool{me.ID: true}
+
+	var mine struct {
+		Value []graphDrive `json:"value"`
+	}
+	if err := c.get(ctx, "/me/drives", &mine); err == nil {
+		for _, d := range mine.Value {
+			if !seen[d.ID] {
+				seen[d.ID] = true
+				drives = append(drives, Drive{ID: d.ID, Name: d.Name, Type: d.DriveType})
+			}
+		}
+	}
+
+	// Las cuentas personales no tienen SharePoint
+	if me.DriveType == TypePersonal {
+		return drives, nil
+	}
+	var sites struct {
+		Value []struct {
+			ID          string `json:"id"`
+			DisplayName string `json:"displayName"`
+		} `json:"value"`
+	}
+	if err := c.get(ctx, "/sites?search=*", &sites); err != nil {
+		// Sin permiso para buscar sitios seguimos con lo que hay
+		return drives, nil
+	}
+	var libraries []Drive
+	for _, s := range sites.Value {
+		var libs struct {
+			Value []graphDrive `json:"value"`
+		}
+		if err := c.get(ctx, "/sites/"+url.PathEscape(s.ID)+"/drives", &libs); err != nil {
+			continue
+		}
+		for _, d := range libs.Value {
+			if !seen[d.ID] {
+				seen[d.ID] = true
+				driveType := d.DriveType
+				if driveType == "" {
+					driveType = TypeDocumentLibrary
+				}
+				libraries = append(libraries, Drive{ID: d.ID, Name: d.Name, Type: driveType, Site: s.DisplayName})
+			}
+		}
+	}
+	sort.Slice(libraries, func(i, j int) bool {
+		return strings.ToLower(libraries[i].Label()) < strings.ToLower(libraries[j].Label())
+	})
+	return append(drives, libraries...), nil
+}
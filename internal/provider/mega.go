@@ -0,0 +1,158 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/mega"
+	"github.com/anabasasoft/cloudmount-wizard/internal/rclone"
+	"github.com/anabasasoft/cloudmount-wizard/internal/settings"
+)
+
+// Mega monta MEGA a través del servidor WebDAV local de MEGAcmd
+type Mega struct{}
+
+func init() {
+	Register(Mega{}, "Mega")
+}
+
+func (Mega) ID() string { return "mega" }
+
+// Label distingue las unidades de cada carpeta servida por separado
+func (Mega) Label(remote string) string {
+	if path := settings.GetOptions(remote).MegaPath; path != "" {
+		return remote + " (MEGA " + path + ")"
+	}
+	return "MEGA (Oficial)"
+}
+
+// Configure inicia sesión en MEGAcmd (params: user, pass, 2fa, path) y crea
+// el remote WebDAV que apunta al puente local de la carpeta path ("" = toda
+// la cuenta). Si ya hay sesión con esa cuenta no hace falta la contraseña.
+func (Mega) Configure(remote string, params map[string]string) error {
+	if err := mega.Login(params["user"], params["pass"], params["2fa"]); err != nil {
+		return fmt.Errorf("Login fallo: %w", err)
+	}
+	// "/Fotos/" y "/Fotos" son la misma carpeta; "/" queda en "" (toda la cuenta)
+	path := strings.TrimSuffix(params["path"], "/")
+	webdavURL, err := mega.GetWebDAVURL(path)
+	if err != nil {
+		return fmt.Errorf("Error puente: %v", err)
+	}
+	opts := map[string]string{
+		"url":    webdavURL,
+		"vendor": "other",
+		"user":   params["user"],
+	}
+	if params["pass"] != "" {
+		opts["pass"] = params["pass"]
+	}
+	err = rclone.CreateConfigWithOpts(remote, "webdav", opts)
+	if err != nil {
+		return err
+	}
+	remoteOpts := settings.GetOptions(remote)
+	remoteOpts.Provider = "mega"
+	remoteOpts.MegaPath = path
+	return settings.SetOptions(remote, remoteOpts)
+}
+
+// PrepareMount arranca MEGAcmd, vuelve a publicar la carpeta por WebDAV y, si
+// el puente ha cambiado de puerto o ruta, actualiza la URL del remote
+func (Mega) PrepareMount(remote string) error {
+	if err := mega.EnsureDaemon(); err != nil {
+		return err
+	}
+	time.Sleep(300 * time.Millisecond)
+	liveURL, err := mega.GetWebDAVURL(settings.GetOptions(remote).MegaPath)
+	if err != nil {
+		return err
+	}
+	conf, err := rclone.GetRemoteConfig(remote)
+	if err != nil {
+		return err
+	}
+	if conf["url"] != liveURL {
+		if err := rclone.UpdateConfig(remote, map[string]string{"url": liveURL}); err != nil {
+			return fmt.Errorf("no se pudo actualizar la URL del puente: %v", err)
+		}
+	}
+	if err := mega.CheckWebDAV(liveURL); err != nil {
+		return err
+	}
+	// Sin cuota de transferencia se puede navegar y subir, pero no descargar
+	if q := mega.CheckTransferQuota(rclone.GetLogFilePath(remote)); q.Exceeded {
+		return Warning{Msg: q.Message() + ".\nLa unidad se monta, pero las descargas fallaran hasta entonces."}
+	}
+	return nil
+}
+
+func (Mega) Quota(remote string, conf map[string]string) (*rclone.Quota, error) {
+	sp, err := mega.GetSpace()
+	if err != nil || sp.Total == 0 {
+		// mega-df no responde: probamos a través del puente WebDAV
+		return rclone.GetQuota(remote)
+	}
+	return &rclone.Quota{Used: sp.Used, Total: sp.Total, Free: sp.Total - sp.Used, Trash: sp.Rubbish}, nil
+}
+
+// Status incluye la salud del servidor de MEGAcmd que vigila el supervisor
+func (Mega) Status(remote string) Status {
+	var st Status
+	h := mega.Daemon.Check()
+	switch {
+	case h.Running:
+		st.Detail = "MEGAcmd activo desde las " + h.Since.Format("15:04")
+		if h.Restarts > 0 {
+			st.Detail += fmt.Sprintf(" (reiniciado %d veces)", h.Restarts)
+		}
+		if account := mega.CurrentAccount(); account != "" {
+			st.Ready = true
+			st.Text = "SESION OK"
+			st.Detail += "\nCuenta: " + account
+		}
+	case h.LastError != "":
+		st.Detail = "MEGAcmd: " + h.LastError
+	default:
+		st.Detail = "MEGAcmd detenido"
+	}
+	if q := mega.CheckTransferQuota(rclone.GetLogFilePath(remote)); q.Exceeded {
+		st.Text = "SIN CUOTA DE TRANSFERENCIA"
+		st.Detail += "\n" + q.Message()
+	}
+	return st
+}
+
+// Logout cierra la sesión de MEGAcmd solo si no quedan más carpetas
+// de la cuenta montadas como otras unidades
+func (Mega) Logout(remote string) error {
+	if len(otherMegaRemotes(remote, nil)) == 0 {
+		mega.Logout()
+	}
+	return nil
+}
+
+// Teardown deja de servir por WebDAV la carpeta del remote, salvo que otra
+// unidad use la misma
+func (Mega) Teardown(remote string) error {
+	path := settings.GetOptions(remote).MegaPath
+	samePath := func(r string) bool { return settings.GetOptions(r).MegaPath == path }
+	if len(otherMegaRemotes(remote, samePath)) > 0 {
+		return nil
+	}
+	return mega.StopWebDAV(path)
+}
+
+// otherMegaRemotes devuelve los demás remotes del puente de MEGAcmd que
+// cumplen match (nil = todos)
+func otherMegaRemotes(remote string, match func(string) bool) []string {
+	remotes, _ := rclone.ListRemotes()
+	var others []string
+	for _, r := range remotes {
+		if r != remote && For(r).ID() == "mega" && (match == nil || match(r)) {
+			others = append(others, r)
+		}
+	}
+	return others
+}
@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"sync"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/rclone"
+	"github.com/anabasasoft/cloudmount-wizard/internal/settings"
+)
+
+// Provider agrupa lo que cambia según el servicio que hay detrás de un
+// remote: la mayoría son backends de rclone sin más, pero otros (Mega)
+// necesitan su propio programa, sesión o servidor puente.
+type Provider interface {
+	// ID es el valor que se guarda en settings.RemoteOptions.Provider
+	ID() string
+	// Label es el nombre que se muestra en la tarjeta del remote
+	Label(remote string) string
+	// Configure crea el remote a partir de los datos del asistente
+	Configure(remote string, params map[string]string) error
+	// PrepareMount deja listo lo que el montaje necesita (servidores, sesión...).
+	// Un error Warning no impide montar: solo hay que mostrárselo al usuario.
+	PrepareMount(remote string) error
+	// Quota devuelve el espacio usado y total. conf es la configuración del
+	// remote en el volcado que ya tiene quien llama (nil si no la tiene).
+	Quota(remote string, conf map[string]string) (*rclone.Quota, error)
+	// Status informa del estado cuando la unidad no está montada
+	Status(remote string) Status
+	// Logout cierra la sesión con el servicio
+	Logout(remote string) error
+	// Teardown deshace lo creado fuera de rclone al borrar el remote
+	Teardown(remote string) error
+}
+
+// Status es el estado de un remote sin montar
+type Status struct {
+	Ready  bool   // Se puede consultar la cuota sin montar
+	Text   string // Texto para la tarjeta ("" = apagado)
+	Detail string // Línea extra en la tarjeta (p. ej. salud del servidor)
+}
+
+// Warning es un aviso de PrepareMount que no impide montar
+type Warning struct {
+	Msg string
+}
+
+func (w Warning) Error() string { return w.Msg }
+
+var (
+	mu       sync.RWMutex
+	registry          = map[string]Provider{}
+	fallback Provider = Rclone{}
+
+	// legacyNames son remotes creados antes de guardar el proveedor en los ajustes
+	legacyNames = map[string]string{}
+)
+
+// Register añade un proveedor. legacy son nombres de remote que, sin
+// proveedor guardado, pertenecen a él.
+func Register(p Provider, legacy ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[p.ID()] = p
+	for _, name := range legacy {
+		legacyNames[name] = p.ID()
+	}
+}
+
+// Get devuelve el proveedor con ese ID (el de rclone si no existe)
+func Get(id string) Provider {
+	mu.RLock()
+	defer mu.RUnlock()
+	if p, ok := registry[id]; ok {
+		return p
+	}
+	return fallback
+}
+
+// For devuelve el proveedor que gestiona un remote
+func For(remote string) Provider {
+	id := settings.GetOptions(remote).Provider
+	if id == "" {
+		mu.RLock()
+		id = legacyNames[remote]
+		mu.RUnlock()
+	}
+	return Get(id)
+}
+
+// IsDefault indica si el remote es un backend de rclone sin nada especial
+func IsDefault(p Provider) bool {
+	return p.ID() == fallback.ID()
+}
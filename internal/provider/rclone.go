@@ -0,0 +1,51 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/rclone"
+)
+
+// Rclone es el proveedor por defecto: un backend de rclone sin más
+type Rclone struct{}
+
+func (Rclone) ID() string { return "rclone" }
+
+func (Rclone) Label(remote string) string { return remote }
+
+// Configure espera el backend en params["type"] y el resto como opciones
+func (Rclone) Configure(remote string, params map[string]string) error {
+	backend := params["type"]
+	if backend == "" {
+		return fmt.Errorf("falta el tipo de backend")
+	}
+	opts := map[string]string{}
+	for k, v := range params {
+		if k != "type" {
+			opts[k] = v
+		}
+	}
+	return rclone.CreateConfigWithOpts(remote, backend, opts)
+}
+
+func (Rclone) PrepareMount(remote string) error { return nil }
+
+// Quota suma la de los miembros en union/combine
+func (Rclone) Quota(remote string, conf map[string]string) (*rclone.Quota, error) {
+	if conf == nil {
+		var err error
+		if conf, err = rclone.GetRemoteConfig(remote); err != nil {
+			return nil, err
+		}
+	}
+	if members := rclone.MemberRemotes(conf); len(members) > 0 {
+		return rclone.AggregateQuota(members)
+	}
+	return rclone.GetQuota(remote)
+}
+
+func (Rclone) Status(remote string) Status { return Status{} }
+
+func (Rclone) Logout(remote string) error { return nil }
+
+func (Rclone) Teardown(remote string) error { return nil }
@@ -0,0 +1,131 @@
+package rclone
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/redact"
+)
+
+// DumpConfig devuelve la configuración de todos los remotes (`rclone config dump`).
+// Las contraseñas vienen ofuscadas, igual que en rclone.conf.
+func DumpConfig() (map[string]map[string]string, error) {
+	output, err := command("config", "dump").Output()
+	if err != nil {
+		return nil, err
+	}
+	var dump map[string]map[string]string
+	if err := json.Unmarshal(output, &dump); err != nil {
+		return nil, err
+	}
+	return dump, nil
+}
+
+// GetRemoteConfig devuelve las opciones de un remote concreto
+func GetRemoteConfig(name string) (map[string]string, error) {
+	dump, err := DumpConfig()
+	if err != nil {
+		return nil, err
+	}
+	conf, ok := dump[name]
+	if !ok {
+		return nil, fmt.Errorf("no existe el remote '%s'", name)
+	}
+	return conf, nil
+}
+
+// GetRemoteType devuelve el backend del remote ("drive", "crypt", ...)
+func GetRemoteType(name string) string {
+	conf, err := GetRemoteConfig(name)
+	if err != nil {
+		return ""
+	}
+	return conf["type"]
+}
+
+// RemoteOf extrae el nombre del remote de una ruta "remote:carpeta"
+func RemoteOf(path string) string {
+	if i := strings.Index(path, ":"); i > 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// CheckRemote comprueba que el remote responde con sus credenciales actuales
+func CheckRemote(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	out, err := commandContext(ctx, "lsf", name+":", "--max-depth", "1", "--dirs-only").CombinedOutput()
+	if ctx.Err() != nil {
+		return fmt.Errorf("'%s' no responde", name)
+	}
+	if err != nil {
+		return fmt.Errorf("'%s' no es accesible: %s", name, lastLine(string(out)))
+	}
+	return nil
+}
+
+// lastLine se queda con la última línea no vacía (el error real de rclone)
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// UpdateConfig cambia opciones de un remote existente (config/update). Igual
+// que al crear, los valores en claro se ofuscan al guardarse.
+func UpdateConfig(name string, opts map[string]string) error {
+	params := map[string]any{
+		"name":       name,
+		"parameters": opts,
+		"opt": map[string]any{
+			"obscure":        true,
+			"nonInteractive": true,
+		},
+	}
+	err := withRC(func(rc *rcServer) error {
+		return rc.call("config/update", params, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("err: %s", redact.Secrets(err.Error(), secretValues(opts)...))
+	}
+	return nil
+}
+
+// oauthToken es el campo "token" que rclone guarda para los backends OAuth
+type oauthToken struct {
+	AccessToken string    `json:"access_token"`
+	Expiry      time.Time `json:"expiry"`
+}
+
+// AccessToken devuelve el access token OAuth vigente del remote. Si ha
+// caducado, una operación de rclone lo renueva y se vuelve a leer.
+func AccessToken(name string) (string, error) {
+	read := func() (oauthToken, error) {
+		var tok oauthToken
+		conf, err := GetRemoteConfig(name)
+		if err != nil {
+			return tok, err
+		}
+		if conf["token"] == "" {
+			return tok, fmt.Errorf("'%s' no tiene token OAuth", name)
+		}
+		err = json.Unmarshal([]byte(conf["token"]), &tok)
+		return tok, err
+	}
+	tok, err := read()
+	if err != nil {
+		return "", err
+	}
+	if !tok.Expiry.IsZero() && time.Until(tok.Expiry) < time.Minute {
+		if err := CheckRemote(name); err != nil {
+			return "", err
+		}
+		if tok, err = read(); err != nil {
+			return "", err
+		}
+	}
+	return tok.AccessToken, nil
+}
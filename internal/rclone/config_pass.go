@@ -0,0 +1,115 @@
+package rclone
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Contraseña de rclone.conf (si está cifrado) y comando equivalente para
+// procesos que no heredan nuestro entorno (unidades systemd).
+var (
+	configPass     string
+	passCommand    string
+	configPassLock sync.RWMutex
+)
+
+// GetConfigFile devuelve la ruta de rclone.conf (respetando RCLONE_CONFIG)
+func GetConfigFile() string {
+	if p := os.Getenv("RCLONE_CONFIG"); p != "" {
+		return p
+	}
+	return filepath.Join(GetConfigDir(), "rclone.conf")
+}
+
+// IsConfigEncrypted detecta si rclone.conf se cifró con `rclone config encryption`
+func IsConfigEncrypted() bool {
+	f, err := os.Open(GetConfigFile())
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return strings.HasPrefix(line, "RCLONE_ENCRYPT_V0:")
+	}
+	return false
+}
+
+// SetConfigPassword fija la contraseña que se pasará a cada invocación de rclone
+func SetConfigPassword(pass string) {
+	configPassLock.Lock()
+	defer configPassLock.Unlock()
+	configPass = pass
+}
+
+// HasConfigPassword indica si ya tenemos la contraseña en memoria
+func HasConfigPassword() bool {
+	configPassLock.RLock()
+	defer configPassLock.RUnlock()
+	return configPass != ""
+}
+
+// SetPasswordCommand define el comando que usarán las unidades systemd
+// (--password-command) para obtener la contraseña sin guardarla en disco
+func SetPasswordCommand(cmdLine string) {
+	configPassLock.Lock()
+	defer configPassLock.Unlock()
+	passCommand = cmdLine
+}
+
+// VerifyConfigPassword comprueba que la contraseña descifra rclone.conf
+func VerifyConfigPassword(pass string) error {
+	cmd := exec.Command("rclone", "listremotes", "--ask-password=false")
+	cmd.Env = append(os.Environ(), "RCLONE_CONFIG_PASS="+pass)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if strings.Contains(string(out), "wrong password") {
+			return fmt.Errorf("contraseña incorrecta")
+		}
+		return fmt.Errorf("no se pudo leer la configuración: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// command prepara una invocación de rclone. Nunca deja que rclone se quede
+// esperando la contraseña por consola: si el config está cifrado se la
+// pasamos por entorno, y si no la tenemos falla en lugar de colgarse.
+func command(args ...string) *exec.Cmd {
+	return commandContext(context.Background(), args...)
+}
+
+// commandContext es como command pero se cancela con el contexto
+func commandContext(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "rclone", append(args, "--ask-password=false")...)
+	configPassLock.RLock()
+	pass := configPass
+	configPassLock.RUnlock()
+	if pass != "" {
+		cmd.Env = append(os.Environ(), "RCLONE_CONFIG_PASS="+pass)
+	}
+	return cmd
+}
+
+// unitPasswordFlag devuelve el flag para la línea ExecStart de la unidad
+func unitPasswordFlag() (string, error) {
+	if !IsConfigEncrypted() {
+		return "", nil
+	}
+	configPassLock.RLock()
+	cmdLine := passCommand
+	configPassLock.RUnlock()
+	if cmdLine == "" {
+		return "", fmt.Errorf("la configuración de rclone está cifrada: guarda la contraseña en el llavero para usar el automontaje")
+	}
+	return " --ask-password=false --password-command " + systemdQuote(cmdLine), nil
+}
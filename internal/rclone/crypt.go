@@ -0,0 +1,88 @@
+package rclone
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Modos de cifrado de nombres que admite el backend crypt
+var FilenameEncryptionModes = []string{"standard", "obfuscate", "off"}
+
+// CryptConfig describe una carpeta cifrada sobre otro remote
+type CryptConfig struct {
+	Name               string
+	Base               string // Remote subyacente
+	Path               string // Carpeta dentro del remote base
+	Password           string
+	Salt               string // password2 de rclone
+	FilenameEncryption string
+	DirectoryNames     bool
+}
+
+// Target devuelve la ruta "base:carpeta" que envuelve el remote crypt
+func (c CryptConfig) Target() string {
+	return c.Base + ":" + strings.Trim(c.Path, "/")
+}
+
+// GenerateSecret devuelve una clave aleatoria fuerte (256 bits)
+func GenerateSecret() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// CreateCrypt crea el remote crypt. Las claves se envían por el API rc.
+func CreateCrypt(c CryptConfig) error {
+	if c.Base == "" {
+		return fmt.Errorf("falta el remote base")
+	}
+	if c.Password == "" || c.Salt == "" {
+		return fmt.Errorf("la contraseña y la sal no pueden estar vacías")
+	}
+	return CreateConfigWithOpts(c.Name, "crypt", map[string]string{
+		"remote":                    c.Target(),
+		"password":                  c.Password,
+		"password2":                 c.Salt,
+		"filename_encryption":       c.FilenameEncryption,
+		"directory_name_encryption": fmt.Sprintf("%t", c.DirectoryNames),
+	})
+}
+
+// CryptBase devuelve el remote base de un remote crypt ("" si no lo es)
+func CryptBase(remoteName string) string {
+	conf, err := GetRemoteConfig(remoteName)
+	if err != nil || conf["type"] != "crypt" {
+		return ""
+	}
+	return RemoteOf(conf["remote"])
+}
+
+// CryptRecoverySheet genera el texto de la hoja de recuperación de claves
+func CryptRecoverySheet(c CryptConfig) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CloudMount Wizard - Hoja de recuperacion\n")
+	fmt.Fprintf(&b, "Generada: %s\n\n", time.Now().Format("2006-01-02 15:04"))
+	fmt.Fprintf(&b, "Unidad cifrada:      %s\n", c.Name)
+	fmt.Fprintf(&b, "Ubicacion:           %s\n", c.Target())
+	fmt.Fprintf(&b, "Cifrado de nombres:  %s\n", c.FilenameEncryption)
+	fmt.Fprintf(&b, "Cifrado de carpetas: %t\n\n", c.DirectoryNames)
+	fmt.Fprintf(&b, "Contraseña (password):  %s\n", c.Password)
+	fmt.Fprintf(&b, "Sal (password2):        %s\n\n", c.Salt)
+	fmt.Fprintf(&b, "Sin estas dos claves los datos NO se pueden recuperar.\n")
+	fmt.Fprintf(&b, "Para recrear la unidad en otro equipo:\n\n")
+	fmt.Fprintf(&b, "  rclone config create %s crypt %s \\\n", shellQuote(c.Name), shellQuote("remote="+c.Target()))
+	fmt.Fprintf(&b, "    filename_encryption=%s directory_name_encryption=%t \\\n", shellQuote(c.FilenameEncryption), c.DirectoryNames)
+	fmt.Fprintf(&b, "    %s %s --obscure\n", shellQuote("password="+c.Password), shellQuote("password2="+c.Salt))
+	return b.String()
+}
+
+// shellQuote entrecomilla un argumento para sh: dentro de '...' nada es
+// especial salvo la propia comilla, que se cierra, se escapa y se reabre
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,45 @@
+package rclone
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCryptRecoverySheetCommand(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh no disponible")
+	}
+	c := CryptConfig{
+		Name:               "Fotos de Ana",
+		Base:               "drive",
+		Path:               "/Copias 'viejas'/",
+		Password:           `it's a "secret" $HOME \n; rm -rf ~`,
+		Salt:               `'';'`,
+		FilenameEncryption: "standard",
+		DirectoryNames:     true,
+	}
+	sheet := CryptRecoverySheet(c)
+	i := strings.Index(sheet, "  rclone config create")
+	if i < 0 {
+		t.Fatalf("la hoja no incluye el comando:\n%s", sheet)
+	}
+
+	// El comando de la hoja, con un rclone que solo imprime sus argumentos
+	script := "rclone() { for a in \"$@\"; do printf '%s\\n' \"$a\"; done; }\n" + sheet[i:]
+	out, err := exec.Command(sh, "-c", script).Output()
+	if err != nil {
+		t.Fatalf("el comando de la hoja no es valido para sh: %v\n%s", err, sheet[i:])
+	}
+	got := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	want := []string{
+		"config", "create", c.Name, "crypt", "remote=" + c.Target(),
+		"filename_encryption=standard", "directory_name_encryption=true",
+		"password=" + c.Password, "password2=" + c.Salt, "--obscure",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("argumentos =\n%q\nesperaba\n%q", got, want)
+	}
+}
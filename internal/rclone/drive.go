@@ -0,0 +1,167 @@
+package rclone
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/redact"
+	"github.com/anabasasoft/cloudmount-wizard/internal/settings"
+)
+
+// DefaultDriveExportFormats hace que Docs, Sheets, Slides y dibujos se vean
+// como documentos que LibreOffice abre directamente
+const DefaultDriveExportFormats = "odt,ods,odp,svg"
+
+// SharedDrive es una unidad compartida (team drive) de Google Drive
+type SharedDrive struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// DriveView es el punto de entrada dentro de una cuenta de Drive:
+// "Mi unidad", "Compartido conmigo" o una unidad compartida
+type DriveView struct {
+	TeamDrive    string
+	SharedWithMe bool
+}
+
+// DriveFolder es una carpeta de Drive identificada por su ID
+type DriveFolder struct {
+	ID   string
+	Name string
+}
+
+func (v DriveView) flags() []string {
+	if v.TeamDrive != "" {
+		return []string{"--drive-team-drive", v.TeamDrive}
+	}
+	if v.SharedWithMe {
+		return []string{"--drive-shared-with-me"}
+	}
+	return nil
+}
+
+// Overrides devuelve las opciones del remote que fijan esta vista
+func (v DriveView) Overrides() map[string]string {
+	o := map[string]string{"team_drive": v.TeamDrive, "shared_with_me": "", "root_folder_id": ""}
+	if v.SharedWithMe {
+		o["shared_with_me"] = "true"
+	}
+	return o
+}
+
+// ListSharedDrives lista las unidades compartidas accesibles (`rclone backend drives`)
+func ListSharedDrives(remoteName string) ([]SharedDrive, error) {
+	out, err := command("backend", "drives", remoteName+":").Output()
+	if err != nil {
+		return nil, fmt.Errorf("no se pudieron listar las unidades compartidas: %s", stderrOf(err))
+	}
+	var drives []SharedDrive
+	if err := json.Unmarshal(out, &drives); err != nil {
+		return nil, err
+	}
+	sort.Slice(drives, func(i, j int) bool { return drives[i].Name < drives[j].Name })
+	return drives, nil
+}
+
+// ListDriveFolders lista las subcarpetas de parentID ("" = raíz de la vista)
+func ListDriveFolders(remoteName string, view DriveView, parentID string) ([]DriveFolder, error) {
+	args := []string{"lsjson", remoteName + ":", "--dirs-only"}
+	args = append(args, view.flags()...)
+	if parentID != "" {
+		args = append(args, "--drive-root-folder-id", parentID)
+	}
+	out, err := command(args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo listar la carpeta: %s", stderrOf(err))
+	}
+	var items []lsjsonItem
+	if err := json.Unmarshal(out, &items); err != nil {
+		return nil, err
+	}
+	var folders []DriveFolder
+	for _, it := range items {
+		folders = append(folders, DriveFolder{ID: it.ID, Name: it.Name})
+	}
+	sort.Slice(folders, func(i, j int) bool { return folders[i].Name < folders[j].Name })
+	return folders, nil
+}
+
+// stderrOf devuelve el error real de rclone cuando se usó Output()
+func stderrOf(err error) string {
+	var ee *exec.ExitError
+	if errors.As(err, &ee) && len(ee.Stderr) > 0 {
+		return lastLine(string(ee.Stderr))
+	}
+	return err.Error()
+}
+
+// CloneRemote crea newName con la configuración de src cambiando las
+// opciones indicadas ("" borra la opción). Los secretos del volcado ya
+// están ofuscados, así que se guardan tal cual.
+func CloneRemote(src, newName string, overrides map[string]string) error {
+	conf, err := GetRemoteConfig(src)
+	if err != nil {
+		return err
+	}
+	provider := conf["type"]
+	params := map[string]string{}
+	for k, v := range conf {
+		if k != "type" {
+			params[k] = v
+		}
+	}
+	for k, v := range overrides {
+		if v == "" {
+			delete(params, k)
+		} else {
+			params[k] = v
+		}
+	}
+	return createRemoteRaw(newName, provider, params)
+}
+
+// createRemoteRaw crea (o reemplaza) un remote con valores que ya vienen
+// ofuscados, como los de `rclone config dump`
+func createRemoteRaw(name, provider string, params map[string]string) error {
+	err := withRC(func(rc *rcServer) error {
+		return rc.call("config/create", map[string]any{
+			"name":       name,
+			"type":       provider,
+			"parameters": params,
+			"opt": map[string]any{
+				"noObscure":      true,
+				"nonInteractive": true,
+			},
+		}, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("err: %s", redact.Secrets(err.Error(), secretValues(params)...))
+	}
+	return nil
+}
+
+// driveFlags traduce los ajustes de Google Drive del remote a flags de rclone
+func driveFlags(opts settings.RemoteOptions) []string {
+	export := opts.DriveExportFormats
+	if export == "" {
+		export = DefaultDriveExportFormats
+	}
+	args := []string{"--drive-export-formats", export}
+	if opts.DriveImportFormats != "" {
+		args = append(args, "--drive-import-formats", opts.DriveImportFormats)
+	}
+	if opts.DriveSkipGdocs {
+		args = append(args, "--drive-skip-gdocs")
+	}
+	if opts.DriveAcknowledgeAbuse {
+		args = append(args, "--drive-acknowledge-abuse")
+	}
+	if opts.DriveUseTrash != nil && !*opts.DriveUseTrash {
+		args = append(args, "--drive-use-trash=false")
+	}
+	return args
+}
@@ -0,0 +1,140 @@
+package rclone
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Las pruebas sustituyen rclone por el propio binario de test: un script
+// "rclone" en el PATH lo relanza con fakeRcloneEnv y TestMain hace de rclone.
+const (
+	fakeRcloneEnv = "CLOUDMOUNT_FAKE_RCLONE"
+	fakeArgvEnv   = "CLOUDMOUNT_FAKE_RCLONE_ARGV"
+)
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fakeRcloneEnv) == "1" {
+		os.Exit(fakeRcloneMain(os.Args[1:]))
+	}
+	os.Exit(m.Run())
+}
+
+// useFakeRclone pone el rclone falso el primero del PATH y devuelve el
+// archivo donde registra la línea de comandos de cada invocación
+func useFakeRclone(t *testing.T) string {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	script := fmt.Sprintf("#!/bin/sh\n%s=1 exec %q \"$@\"\n", fakeRcloneEnv, exe)
+	if err := os.WriteFile(filepath.Join(dir, "rclone"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	argvLog := filepath.Join(dir, "argv.log")
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv(fakeArgvEnv, argvLog)
+	return argvLog
+}
+
+// recordedArgv devuelve las líneas de comandos registradas por el rclone falso
+func recordedArgv(t *testing.T, argvLog string) [][]string {
+	t.Helper()
+	data, err := os.ReadFile(argvLog)
+	if err != nil {
+		t.Fatalf("el rclone falso no se ejecutó: %v", err)
+	}
+	var all [][]string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var argv []string
+		if err := json.Unmarshal([]byte(line), &argv); err != nil {
+			t.Fatal(err)
+		}
+		all = append(all, argv)
+	}
+	return all
+}
+
+// fakeRcloneMain imita lo que la app usa de rclone: `rcd` con el API rc
+// (config/create y config/update fallan si el remote se llama "falla",
+// devolviendo los parámetros recibidos en el error) y `lsjson` vacío, con un
+// aviso en stderr; `lsjson :s3:falla` falla mostrando la clave secreta.
+// `listremotes` devuelve los remotes de fakeRemotes.
+func fakeRcloneMain(args []string) int {
+	if f, err := os.OpenFile(os.Getenv(fakeArgvEnv), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600); err == nil {
+		line, _ := json.Marshal(append([]string{"rclone"}, args...))
+		f.Write(append(line, '\n'))
+		f.Close()
+	}
+	if len(args) == 0 {
+		return 1
+	}
+	switch args[0] {
+	case "rcd":
+		addr := ""
+		for i, a := range args {
+			if a == "--rc-addr" && i+1 < len(args) {
+				addr = args[i+1]
+			}
+		}
+		return fakeRCD(addr)
+	case "lsjson":
+		fmt.Fprintln(os.Stderr, "NOTICE: aviso de prueba en stderr")
+		if len(args) > 1 && args[1] == ":s3:falla" {
+			fmt.Fprintln(os.Stderr, "ERROR : firma no valida para "+os.Getenv("RCLONE_S3_SECRET_ACCESS_KEY"))
+			return 1
+		}
+		fmt.Println("[]")
+	case "listremotes":
+		for _, r := range fakeRemotes {
+			fmt.Println(r + ":")
+		}
+	case "config":
+		if len(args) > 1 && args[1] == "dump" {
+			fmt.Println("{}")
+		}
+	}
+	return 0
+}
+
+// fakeRemotes son los remotes que dice tener configurados el rclone falso
+var fakeRemotes = []string{"Drive", "Fotos año"}
+
+func fakeRCD(addr string) int {
+	user, pass := os.Getenv("RCLONE_RC_USER"), os.Getenv("RCLONE_RC_PASS")
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != user || p != pass {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var in map[string]any
+		json.NewDecoder(r.Body).Decode(&in)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/core/version":
+			fmt.Fprint(w, `{"version":"v0.0.0-fake"}`)
+		case "/config/create", "/config/update":
+			if in["name"] == "falla" {
+				params, _ := json.Marshal(in["parameters"])
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(map[string]string{"error": "parametros rechazados: " + string(params)})
+				return
+			}
+			fmt.Fprint(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":"metodo desconocido"}`)
+		}
+	})
+	if err := http.ListenAndServe(addr, handler); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
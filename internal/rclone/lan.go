@@ -0,0 +1,118 @@
+package rclone
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Modos TLS del asistente FTP
+const (
+	FTPTLSNone     = "none"
+	FTPTLSExplicit = "explicit" // FTPES: AUTH TLS sobre el puerto 21
+	FTPTLSImplicit = "implicit" // FTPS: TLS desde el inicio, normalmente puerto 990
+)
+
+// SMBConfig son los datos de una carpeta compartida SMB/CIFS
+type SMBConfig struct {
+	Name     string
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Domain   string
+}
+
+// FTPConfig son los datos de un servidor FTP/FTPS
+type FTPConfig struct {
+	Name        string
+	Host        string
+	Port        int
+	User        string
+	Password    string
+	TLS         string
+	DisableEPSV bool // Solo PASV clásico, para NAS antiguos
+	SkipVerify  bool // Certificado autofirmado del NAS
+}
+
+// CreateSMB crea un remote smb
+func CreateSMB(c SMBConfig) error {
+	if c.Host == "" {
+		return fmt.Errorf("falta el servidor")
+	}
+	opts := map[string]string{
+		"host": c.Host,
+		"user": c.User,
+		"pass": c.Password,
+	}
+	if c.Domain != "" {
+		opts["domain"] = c.Domain
+	}
+	if c.Port != 0 && c.Port != 445 {
+		opts["port"] = strconv.Itoa(c.Port)
+	}
+	return CreateConfigWithOpts(c.Name, "smb", opts)
+}
+
+// CreateFTP crea un remote ftp (siempre en modo pasivo, como hace rclone)
+func CreateFTP(c FTPConfig) error {
+	opts, err := ftpOptions(c)
+	if err != nil {
+		return err
+	}
+	return CreateConfigWithOpts(c.Name, "ftp", opts)
+}
+
+// ftpOptions traduce la configuración del asistente a opciones de rclone
+func ftpOptions(c FTPConfig) (map[string]string, error) {
+	if c.Host == "" {
+		return nil, fmt.Errorf("falta el servidor")
+	}
+	opts := map[string]string{
+		"host": c.Host,
+		"user": c.User,
+		"pass": c.Password,
+	}
+	if c.Port != 0 {
+		opts["port"] = strconv.Itoa(c.Port)
+	} else if c.TLS == FTPTLSImplicit {
+		// rclone usa el 21 aunque tls=true: FTPS implícito escucha en el 990
+		opts["port"] = "990"
+	}
+	switch c.TLS {
+	case FTPTLSExplicit:
+		opts["explicit_tls"] = "true"
+	case FTPTLSImplicit:
+		opts["tls"] = "true"
+	}
+	if c.DisableEPSV {
+		opts["disable_epsv"] = "true"
+	}
+	if c.SkipVerify && c.TLS != FTPTLSNone {
+		opts["no_check_certificate"] = "true"
+	}
+	return opts, nil
+}
+
+// isLANBackend indica si el backend suele apuntar a almacenamiento de red local
+func isLANBackend(remoteType string) bool {
+	switch remoteType {
+	case "smb", "ftp", "sftp":
+		return true
+	}
+	return false
+}
+
+// vfsFlags devuelve la caché VFS según el tipo de remote. En red local no
+// compensa copiar todo a disco y conviene ver pronto los cambios de otros
+// equipos que usan el mismo NAS.
+func vfsFlags(remoteType string) []string {
+	if isLANBackend(remoteType) {
+		return []string{
+			"--vfs-cache-mode", "writes",
+			"--dir-cache-time", "30s",
+			"--vfs-cache-max-age", "1h",
+			"--buffer-size", "32M",
+		}
+	}
+	return []string{"--vfs-cache-mode", "full"}
+}
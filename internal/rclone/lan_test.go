@@ -0,0 +1,51 @@
+package rclone
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFTPOptions(t *testing.T) {
+	base := map[string]string{"host": "nas.local", "user": "ana", "pass": "clave"}
+	with := func(extra map[string]string) map[string]string {
+		m := map[string]string{}
+		for k, v := range base {
+			m[k] = v
+		}
+		for k, v := range extra {
+			m[k] = v
+		}
+		return m
+	}
+	tests := []struct {
+		name string
+		cfg  FTPConfig
+		want map[string]string
+	}{
+		{"sin TLS", FTPConfig{TLS: FTPTLSNone}, with(nil)},
+		{"sin TLS con puerto", FTPConfig{TLS: FTPTLSNone, Port: 2121}, with(map[string]string{"port": "2121"})},
+		{"sin TLS ignora no_check_certificate", FTPConfig{TLS: FTPTLSNone, SkipVerify: true}, with(nil)},
+		{"explicito", FTPConfig{TLS: FTPTLSExplicit}, with(map[string]string{"explicit_tls": "true"})},
+		{"explicito sin verificar", FTPConfig{TLS: FTPTLSExplicit, SkipVerify: true},
+			with(map[string]string{"explicit_tls": "true", "no_check_certificate": "true"})},
+		{"implicito usa el 990", FTPConfig{TLS: FTPTLSImplicit}, with(map[string]string{"tls": "true", "port": "990"})},
+		{"implicito con puerto", FTPConfig{TLS: FTPTLSImplicit, Port: 2990}, with(map[string]string{"tls": "true", "port": "2990"})},
+		{"sin EPSV", FTPConfig{TLS: FTPTLSNone, DisableEPSV: true}, with(map[string]string{"disable_epsv": "true"})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Host, tt.cfg.User, tt.cfg.Password = "nas.local", "ana", "clave"
+			got, err := ftpOptions(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ftpOptions = %v, esperaba %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ftpOptions(FTPConfig{}); err == nil {
+		t.Fatal("ftpOptions sin servidor debía fallar")
+	}
+}
@@ -9,9 +9,11 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
+	"github.com/anabasasoft/cloudmount-wizard/internal/redact"
 	"github.com/anabasasoft/cloudmount-wizard/internal/settings"
 )
 
@@ -42,10 +44,22 @@ type Quota struct {
 func MountRemote(remoteName string) (string, error) {
 	mountPoint := GetMountPath(remoteName)
 
+	// Un crypt nunca se monta si su remote base tiene las credenciales rotas
+	if base := CryptBase(remoteName); base != "" {
+		if err := CheckRemote(base); err != nil {
+			return "", fmt.Errorf("no se monta '%s': %v", remoteName, err)
+		}
+	}
+
+	// Certificado fijado, CA propia o certificado cliente: comprobamos antes de lanzar rclone
+	if err := VerifyServerTLS(remoteName); err != nil {
+		return "", err
+	}
+
 	// --- FASE DE LIMPIEZA (ANTI-DUPLICADOS) ---
 	// 1. Matamos específicamente el proceso rclone que esté montando ESTA unidad.
 	// El patrón busca "rclone mount NombreRemoto:" para no matar otras nubes.
-	cmdKill := exec.Command("pkill", "-f", fmt.Sprintf("rclone mount %s:", remoteName))
+	cmdKill := exec.Command("pkill", "-f", "rclone mount "+regexp.QuoteMeta(remoteName+":"))
 	cmdKill.Run()
 
 	// 2. Comprobamos si el punto de montaje sigue ocupado
@@ -70,21 +84,22 @@ func MountRemote(remoteName string) (string, error) {
 	// Si el usuario tiene activado el automontaje por Systemd, usamos el servicio
 	if IsAutomountEnabled(remoteName) {
 		// Usamos 'restart' para asegurar que levanta limpio después del pkill
-		exec.Command("systemctl", "--user", "restart", "rclone-"+remoteName+".service").Run()
+		exec.Command("systemctl", "--user", "restart", UnitName(remoteName)).Run()
 		return mountPoint, nil
 	}
 
 	// Configuración manual de rclone
 	opts := settings.GetOptions(remoteName)
 	args := []string{
-		"mount", remoteName + ":", mountPoint,
+		"mount", remoteName + ":" + opts.RemotePath, mountPoint,
 		"--daemon",
-		"--vfs-cache-mode", "full",
 		"--volname", remoteName,
 		"--log-level", "INFO",
 		// AQUÍ USAMOS LA FUNCIÓN ACTUALIZADA PARA SEPARAR LOS LOGS
 		"--log-file", GetLogFilePath(remoteName),
 	}
+	remoteType := GetRemoteType(remoteName)
+	args = append(args, vfsFlags(remoteType)...)
 
 	if opts.ReadOnly {
 		args = append(args, "--read-only")
@@ -99,8 +114,12 @@ func MountRemote(remoteName string) (string, error) {
 	if opts.RootFolderID != "" {
 		args = append(args, "--drive-root-folder-id", opts.RootFolderID)
 	}
+	if remoteType == "drive" {
+		args = append(args, driveFlags(opts)...)
+	}
+	args = append(args, tlsFlags(opts)...)
 
-	cmd := exec.Command("rclone", args...)
+	cmd := command(args...)
 	if output, err := cmd.CombinedOutput(); err != nil {
 		return "", fmt.Errorf("error mount: %s", string(output))
 	}
@@ -116,6 +135,30 @@ func EnableAutomount(remoteName string) error {
 		time.Sleep(1 * time.Second)
 	}
 
+	if err := writeAutomountUnit(remoteName); err != nil {
+		return err
+	}
+	exec.Command("systemctl", "--user", "daemon-reload").Run()
+	return exec.Command("systemctl", "--user", "enable", "--now", UnitName(remoteName)).Run()
+}
+
+// UpdateAutomount reescribe la unidad de automontaje del remote, si la tiene,
+// para que recoja los ajustes guardados. No reinicia el montaje: los cambios
+// se aplican la próxima vez que systemd lo arranque.
+func UpdateAutomount(remoteName string) error {
+	if _, err := os.Stat(filepath.Join(getServiceDir(), UnitName(remoteName))); err != nil {
+		return nil
+	}
+	if err := writeAutomountUnit(remoteName); err != nil {
+		return err
+	}
+	exec.Command("systemctl", "--user", "daemon-reload").Run()
+	return nil
+}
+
+// writeAutomountUnit escribe la unidad systemd con los ajustes actuales
+func writeAutomountUnit(remoteName string) error {
+	mountPoint := GetMountPath(remoteName)
 	rcloneBin, err := exec.LookPath("rclone")
 	if err != nil {
 		return fmt.Errorf("no rclone")
@@ -125,21 +168,50 @@ func EnableAutomount(remoteName string) error {
 		fuserBin = "/bin/fusermount"
 	}
 
-	flags := fmt.Sprintf("--vfs-cache-mode full --no-checksum --no-modtime --volname %s", remoteName)
+	flags := "--no-checksum --no-modtime --volname " + systemdQuote(remoteName)
+	remoteType := GetRemoteType(remoteName)
+	for _, f := range vfsFlags(remoteType) {
+		flags += " " + systemdQuote(f)
+	}
 
 	opts := settings.GetOptions(remoteName)
 	if opts.ReadOnly {
 		flags += " --read-only"
 	}
 	if opts.CacheSize != "" {
-		flags += " --vfs-cache-max-size " + opts.CacheSize
+		flags += " --vfs-cache-max-size " + systemdQuote(opts.CacheSize)
 	}
 	if opts.BwLimit != "" {
-		flags += " --bwlimit " + opts.BwLimit
+		flags += " --bwlimit " + systemdQuote(opts.BwLimit)
 	}
 
 	if opts.RootFolderID != "" {
-		flags += " --drive-root-folder-id " + opts.RootFolderID
+		flags += " --drive-root-folder-id " + systemdQuote(opts.RootFolderID)
+	}
+	if remoteType == "drive" {
+		for _, f := range driveFlags(opts) {
+			flags += " " + systemdQuote(f)
+		}
+	}
+	for _, f := range tlsFlags(opts) {
+		flags += " " + systemdQuote(f)
+	}
+
+	passFlag, err := unitPasswordFlag()
+	if err != nil {
+		return err
+	}
+	flags += passFlag
+
+	// Para un crypt, systemd comprueba antes que el remote base responde
+	preCheck := ""
+	if base := CryptBase(remoteName); base != "" {
+		preCheck = fmt.Sprintf("ExecStartPre=%s lsf %s --max-depth 1 --dirs-only%s\n",
+			systemdQuote(rcloneBin), systemdQuote(base+":"), passFlag)
+	}
+	// Con certificado fijado, la propia app lo comprueba antes de montar
+	if opts.PinnedCert != "" && helperExe != "" {
+		preCheck += fmt.Sprintf("ExecStartPre=%s --verify-tls %s\n", systemdQuote(helperExe), systemdQuote(remoteName))
 	}
 
 	serviceContent := fmt.Sprintf(`[Unit]
@@ -150,46 +222,44 @@ func EnableAutomount(remoteName string) error {
 	[Service]
 	Type=notify
 	ExecStartPre=/usr/bin/mkdir -p %s
-	ExecStart=%s mount %s: %s %s
+	%s
+	ExecStart=%s mount %s %s %s
 	ExecStop=%s -u %s
 	Restart=on-failure
 	RestartSec=10
 
 	[Install]
 	WantedBy=default.target
-	`, remoteName, mountPoint, rcloneBin, remoteName, mountPoint, flags, fuserBin, mountPoint)
-
-	path := getServicePath(remoteName)
-	if err := os.WriteFile(path, []byte(serviceContent), 0644); err != nil {
-		return err
-	}
-	exec.Command("systemctl", "--user", "daemon-reload").Run()
-	return exec.Command("systemctl", "--user", "enable", "--now", "rclone-"+remoteName+".service").Run()
-}
+	`, strings.ReplaceAll(remoteName, "%", "%%"), systemdQuote(mountPoint), preCheck, systemdQuote(rcloneBin),
+		systemdQuote(remoteName+":"+opts.RemotePath), systemdQuote(mountPoint), flags, systemdQuote(fuserBin), systemdQuote(mountPoint))
 
-func CreateConfig(name, provider string) error {
-	cmd := exec.Command("rclone", "config", "create", name, provider)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("error: %s", string(output))
-	}
-	return nil
+	return os.WriteFile(getServicePath(remoteName), []byte(serviceContent), 0644)
 }
 
+// CreateConfigWithOpts crea el remote a través del API rc (config/create)
+// para que contraseñas y claves no aparezcan en los argumentos del proceso.
+// Los valores en claro se ofuscan al guardarse, como hace `rclone config`.
 func CreateConfigWithOpts(name, provider string, opts map[string]string) error {
-	args := []string{"config", "create", name, provider}
-	for key, value := range opts {
-		args = append(args, fmt.Sprintf("%s=%s", key, value))
-	}
-	cmd := exec.Command("rclone", args...)
-	if out, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("err: %s", string(out))
+	params := map[string]any{
+		"name":       name,
+		"type":       provider,
+		"parameters": opts,
+		"opt": map[string]any{
+			"obscure":        true,
+			"nonInteractive": true,
+		},
+	}
+	err := withRC(func(rc *rcServer) error {
+		return rc.call("config/create", params, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("err: %s", redact.Secrets(err.Error(), secretValues(opts)...))
 	}
 	return nil
 }
 
 func ListRemotes() ([]string, error) {
-	cmd := exec.Command("rclone", "listremotes")
+	cmd := command("listremotes")
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, err
@@ -206,7 +276,7 @@ func ListRemotes() ([]string, error) {
 }
 
 func GetQuota(remoteName string) (*Quota, error) {
-	cmd := exec.Command("rclone", "about", remoteName+":", "--json")
+	cmd := command("about", remoteName+":", "--json")
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, err
@@ -232,7 +302,7 @@ func FormatBytes(size int64) string {
 
 func UnmountRemote(remoteName string) error {
 	if IsAutomountEnabled(remoteName) {
-		exec.Command("systemctl", "--user", "stop", "rclone-"+remoteName+".service").Run()
+		exec.Command("systemctl", "--user", "stop", UnitName(remoteName)).Run()
 		return nil
 	}
 	mountPoint := GetMountPath(remoteName)
@@ -243,12 +313,15 @@ func UnmountRemote(remoteName string) error {
 }
 
 func RenameRemote(oldName, newName string) error {
+	// Antes de tocar nada: si no podemos renombrar, la unidad sigue como estaba
+	if IsConfigEncrypted() {
+		return fmt.Errorf("no se puede renombrar con la configuración cifrada")
+	}
 	if IsAutomountEnabled(oldName) {
 		DisableAutomount(oldName)
 	}
 	UnmountRemote(oldName)
-	configDir, _ := os.UserConfigDir()
-	configPath := filepath.Join(configDir, "rclone", "rclone.conf")
+	configPath := GetConfigFile()
 	content, _ := os.ReadFile(configPath)
 	newContent := strings.Replace(string(content), "["+oldName+"]", "["+newName+"]", 1)
 	os.WriteFile(configPath, []byte(newContent), 0644)
@@ -259,24 +332,30 @@ func RenameRemote(oldName, newName string) error {
 func DeleteRemote(remoteName string) error {
 	DisableAutomount(remoteName)
 	UnmountRemote(remoteName)
-	exec.Command("rclone", "config", "delete", remoteName).Run()
+	dump, _ := DumpConfig()
+	command("config", "delete", remoteName).Run()
+	removeImportedKey(remoteName, dump)
 	os.Remove(GetMountPath(remoteName))
-	return nil
+	return settings.DeleteOptions(remoteName)
 }
 
-func getServicePath(remoteName string) string {
+func getServiceDir() string {
 	home, _ := os.UserHomeDir()
-	dir := filepath.Join(home, ".config", "systemd", "user")
+	return filepath.Join(home, ".config", "systemd", "user")
+}
+
+func getServicePath(remoteName string) string {
+	dir := getServiceDir()
 	os.MkdirAll(dir, 0755)
-	return filepath.Join(dir, "rclone-"+remoteName+".service")
+	return filepath.Join(dir, UnitName(remoteName))
 }
 
 func IsAutomountEnabled(remoteName string) bool {
-	return exec.Command("systemctl", "--user", "is-enabled", "rclone-"+remoteName+".service").Run() == nil
+	return exec.Command("systemctl", "--user", "is-enabled", UnitName(remoteName)).Run() == nil
 }
 
 func DisableAutomount(remoteName string) error {
-	name := "rclone-" + remoteName + ".service"
+	name := UnitName(remoteName)
 	exec.Command("systemctl", "--user", "stop", name).Run()
 	exec.Command("systemctl", "--user", "disable", name).Run()
 	os.Remove(getServicePath(remoteName))
@@ -307,7 +386,7 @@ func IsMounted(path string) bool {
 	}
 
 	// Método 3: Verificar si hay proceso rclone montando este path
-	cmd = exec.Command("pgrep", "-f", "rclone.*"+filepath.Base(path))
+	cmd = exec.Command("pgrep", "-f", "rclone.*"+regexp.QuoteMeta(filepath.Base(path)))
 	return cmd.Run() == nil
 }
 
@@ -317,5 +396,5 @@ func OpenFileManager(path string) {
 
 func GetMountPath(remoteName string) string {
 	home, _ := os.UserHomeDir()
-	return filepath.Join(home, "Nubes", remoteName)
+	return filepath.Join(home, "Nubes", MountDirName(remoteName))
 }
@@ -0,0 +1,42 @@
+package rclone
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/settings"
+)
+
+func TestUpdateAutomount(t *testing.T) {
+	useFakeRclone(t)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	// Sin unidad instalada no se crea ninguna
+	if err := UpdateAutomount("Drive"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(getServicePath("Drive")); !os.IsNotExist(err) {
+		t.Fatal("no debía instalarse una unidad que el usuario no pidió")
+	}
+
+	if err := os.WriteFile(getServicePath("Drive"), []byte("[Service]\nExecStart=rclone mount antiguo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := settings.SetOptions("Drive", settings.RemoteOptions{BwLimit: "2M", RootFolderID: "abc123"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateAutomount("Drive"); err != nil {
+		t.Fatal(err)
+	}
+	unit, err := os.ReadFile(getServicePath("Drive"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`--bwlimit "2M"`, `--drive-root-folder-id "abc123"`} {
+		if !strings.Contains(string(unit), want) {
+			t.Errorf("la unidad reescrita no lleva %s:\n%s", want, unit)
+		}
+	}
+}
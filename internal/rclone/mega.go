@@ -0,0 +1,65 @@
+package rclone
+
+import "fmt"
+
+// CreateMega crea un remote con el backend mega de rclone, que no necesita
+// MEGAcmd. Se comprueba el acceso al momento para detectar credenciales o
+// código 2FA erróneos antes de guardar la unidad.
+func CreateMega(name, user, pass, code2FA string) error {
+	opts := map[string]string{"user": user, "pass": pass}
+	if code2FA != "" {
+		opts["2fa"] = code2FA
+	}
+	if err := CreateConfigWithOpts(name, "mega", opts); err != nil {
+		return err
+	}
+	if err := CheckRemote(name); err != nil {
+		command("config", "delete", name).Run()
+		return fmt.Errorf("no se pudo entrar en Mega: %v", err)
+	}
+	return nil
+}
+
+// MigrateMegaBridge convierte un remote WebDAV del puente de MEGAcmd en un
+// remote del backend mega con el mismo nombre, usuario y contraseña. pass
+// solo hace falta si el puente no la guardó (se reutilizó la sesión de
+// MEGAcmd). Si el acceso falla se restaura la configuración anterior.
+func MigrateMegaBridge(name, pass, code2FA string) error {
+	old, err := GetRemoteConfig(name)
+	if err != nil {
+		return err
+	}
+	if old["type"] != "webdav" || old["user"] == "" {
+		return fmt.Errorf("'%s' no es un remote del puente de MEGAcmd", name)
+	}
+
+	params := map[string]string{"user": old["user"]}
+	if code2FA != "" {
+		params["2fa"] = code2FA
+	}
+	switch {
+	case pass != "":
+		params["pass"] = pass
+		err = CreateConfigWithOpts(name, "mega", params)
+	case old["pass"] != "":
+		// La contraseña del volcado ya está ofuscada: se copia sin volver a ofuscar
+		params["pass"] = old["pass"]
+		err = createRemoteRaw(name, "mega", params)
+	default:
+		return fmt.Errorf("falta la contraseña de la cuenta de MEGA")
+	}
+	if err != nil {
+		return err
+	}
+	if err := CheckRemote(name); err != nil {
+		restore := map[string]string{}
+		for k, v := range old {
+			if k != "type" {
+				restore[k] = v
+			}
+		}
+		createRemoteRaw(name, old["type"], restore)
+		return fmt.Errorf("no se pudo entrar en Mega con el backend de rclone: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package rclone
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// remoteNameRe reproduce las reglas de rclone para nombres de remotes:
+// letras, numeros, '_', '-', '.', '+', '@' y espacios, sin empezar por
+// '-' o espacio y sin terminar en espacio.
+var remoteNameRe = regexp.MustCompile(`^[\w\p{L}\p{N}.+@]+(?:[ -]+[\w\p{L}\p{N}.+@-]+)*$`)
+
+// ValidateRemoteName comprueba que el nombre cumple las reglas de rclone
+func ValidateRemoteName(name string) error {
+	if name == "" {
+		return fmt.Errorf("el nombre no puede estar vacío")
+	}
+	if !remoteNameRe.MatchString(name) {
+		return fmt.Errorf("solo letras, números, espacios y _ - . + @ (sin empezar por - o espacio ni terminar en espacio)")
+	}
+	return nil
+}
+
+// NewRemoteNameValidator devuelve un validador que aplica ValidateRemoteName
+// y además rechaza nombres de remotes que ya existen o que usarían la misma
+// carpeta de montaje que otro. Lee la lista de remotes una sola vez, porque
+// se usa como Validator de los formularios, que se valida en cada pulsación.
+func NewRemoteNameValidator() func(string) error {
+	// Sin lista no podemos detectar duplicados; rclone lo rechazará luego
+	remotes, _ := ListRemotes()
+	return func(name string) error {
+		if err := ValidateRemoteName(name); err != nil {
+			return err
+		}
+		dir := MountDirName(name)
+		for _, r := range remotes {
+			if r == name {
+				return fmt.Errorf("ya existe una unidad llamada '%s'", name)
+			}
+			if MountDirName(r) == dir {
+				return fmt.Errorf("'%s' usaría la misma carpeta que '%s'", name, r)
+			}
+		}
+		return nil
+	}
+}
+
+// SystemdEscape escapa una cadena con la misma semántica que `systemd-escape`
+// (modo no-path): '/' pasa a '-', y todo lo que no sea alfanumérico, ':', '_'
+// o '.' (ni un '.' inicial) se codifica como \xHH.
+func SystemdEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '/':
+			b.WriteByte('-')
+		case c == '.' && i == 0:
+			fmt.Fprintf(&b, `\x%02x`, c)
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9',
+			c == ':', c == '_', c == '.':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, `\x%02x`, c)
+		}
+	}
+	return b.String()
+}
+
+// UnitName devuelve el nombre de la unidad systemd de automontaje del remote.
+// Si existe una unidad creada con el esquema antiguo (sin escapar) se respeta.
+func UnitName(remoteName string) string {
+	legacy := "rclone-" + remoteName + ".service"
+	name := "rclone-" + SystemdEscape(remoteName) + ".service"
+	if legacy != name {
+		if _, err := os.Stat(filepath.Join(getServiceDir(), legacy)); err == nil {
+			return legacy
+		}
+	}
+	return name
+}
+
+// MountDirName convierte el nombre del remote en un nombre de carpeta seguro
+func MountDirName(remoteName string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == 0 {
+			return '_'
+		}
+		return r
+	}, remoteName)
+	if name == "" || name == "." || name == ".." {
+		name = strings.ReplaceAll("_"+name, ".", "_")
+	}
+	return name
+}
+
+// systemdQuote entrecomilla un argumento para una línea Exec* de systemd,
+// escapando también los especificadores '%' y las variables '$'.
+func systemdQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$")
+	return `"` + r.Replace(s) + `"`
+}
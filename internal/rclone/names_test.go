@@ -0,0 +1,66 @@
+package rclone
+
+import "testing"
+
+func TestSystemdEscape(t *testing.T) {
+	// Salidas de `systemd-escape` para las mismas cadenas
+	for in, want := range map[string]string{
+		"Drive":     "Drive",
+		"Mi Drive":  `Mi\x20Drive`,
+		"Fotos año": `Fotos\x20a\xc3\xb1o`,
+		"50%":       `50\x25`,
+		"a/b":       "a-b",
+		"-x":        `\x2dx`,
+		".oculto":   `\x2eoculto`,
+		"a.b":       "a.b",
+	} {
+		if got := SystemdEscape(in); got != want {
+			t.Errorf("SystemdEscape(%q) = %q, esperaba %q", in, got, want)
+		}
+	}
+}
+
+func TestMountDirName(t *testing.T) {
+	for in, want := range map[string]string{
+		"Mi Drive":  "Mi Drive",
+		"Fotos año": "Fotos año",
+		"50%":       "50%",
+		"a/b":       "a_b",
+		"-x":        "-x",
+		"":          "_",
+		".":         "__",
+		"..":        "___",
+		".oculto":   ".oculto",
+	} {
+		if got := MountDirName(in); got != want {
+			t.Errorf("MountDirName(%q) = %q, esperaba %q", in, got, want)
+		}
+	}
+}
+
+func TestNewRemoteNameValidator(t *testing.T) {
+	useFakeRclone(t)
+	validate := NewRemoteNameValidator()
+	for _, tc := range []struct {
+		name string
+		ok   bool
+	}{
+		{"Mi Drive", true},
+		{"Fotos 2024", true},
+		{"Música", true},
+		{"ana@ejemplo.com", true},
+		{"copia-diaria_2", true},
+		{"", false},
+		{"-x", false},
+		{" Drive", false},
+		{"Drive ", false},
+		{"a/b", false},
+		{"50%", false},
+		{"Drive", false},     // ya existe
+		{"Fotos año", false}, // ya existe, con unicode
+	} {
+		if err := validate(tc.name); (err == nil) != tc.ok {
+			t.Errorf("%q: error = %v, esperaba valido=%v", tc.name, err, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package rclone
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var authURLRe = regexp.MustCompile(`https?://\S+`)
+
+// AuthorizeCommand es lo que el usuario ejecuta en otra máquina con navegador
+func AuthorizeCommand(provider string) string {
+	return fmt.Sprintf("rclone authorize %q", provider)
+}
+
+// Authorize ejecuta `rclone authorize` sin abrir navegador. onURL recibe la
+// dirección que hay que visitar en cuanto rclone la imprime; la función
+// termina con el token o cuando se cancela el contexto.
+func Authorize(ctx context.Context, provider string, onURL func(string)) (string, error) {
+	output, err := runOAuthCommand(ctx, onURL, "authorize", provider, "--auth-no-open-browser")
+	if err != nil {
+		return "", err
+	}
+	return ExtractToken(output)
+}
+
+// runOAuthCommand ejecuta un comando de rclone que abre el servidor OAuth
+// local, avisa con la URL de autorización y devuelve toda la salida
+func runOAuthCommand(ctx context.Context, onURL func(string), args ...string) (string, error) {
+	cmd := commandContext(ctx, args...)
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("error iniciando rclone %s: %v", args[0], err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		pw.Close()
+		done <- err
+	}()
+
+	var output bytes.Buffer
+	urlSent := false
+	scanner := bufio.NewScanner(pr)
+	for scanner.Scan() {
+		line := scanner.Text()
+		output.WriteString(line + "\n")
+		if !urlSent && strings.Contains(line, "/auth?state=") {
+			if u := authURLRe.FindString(line); u != "" {
+				urlSent = true
+				onURL(u)
+			}
+		}
+	}
+	io.Copy(io.Discard, pr)
+	err := <-done
+
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	if err != nil {
+		return "", fmt.Errorf("la autorizacion fallo: %s", lastLine(output.String()))
+	}
+	return output.String(), nil
+}
+
+// ExtractToken saca el token de la salida de `rclone authorize`: admite el
+// bloque completo "Paste the following... <---End paste" o solo el JSON
+func ExtractToken(text string) (string, error) {
+	if i := strings.Index(text, "--->"); i >= 0 {
+		text = text[i+len("--->"):]
+	}
+	if i := strings.Index(text, "<---End paste"); i >= 0 {
+		text = text[:i]
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", fmt.Errorf("no hay ningun token")
+	}
+
+	// Algunas versiones de rclone lo entregan en base64
+	data := []byte(text)
+	if !strings.HasPrefix(text, "{") {
+		decoded, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			decoded, err = base64.RawURLEncoding.DecodeString(text)
+		}
+		if err != nil {
+			return "", fmt.Errorf("el texto pegado no es un token de rclone")
+		}
+		data = decoded
+	}
+
+	var tok map[string]any
+	if err := json.Unmarshal(data, &tok); err != nil {
+		return "", fmt.Errorf("el texto pegado no es un token de rclone")
+	}
+	if tok["access_token"] == nil && tok["refresh_token"] == nil {
+		return "", fmt.Errorf("al token le falta access_token/refresh_token")
+	}
+	var compact bytes.Buffer
+	json.Compact(&compact, data)
+	return compact.String(), nil
+}
+
+// CreateOAuthRemote crea el remote con un token ya obtenido
+func CreateOAuthRemote(name, provider, token string) error {
+	return CreateConfigWithOpts(name, provider, map[string]string{"token": token})
+}
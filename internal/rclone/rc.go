@@ -0,0 +1,159 @@
+package rclone
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// rcServer es un `rclone rcd` temporal escuchando solo en localhost.
+// Lo usamos para crear remotes con credenciales: los secretos viajan en el
+// cuerpo HTTP y nunca aparecen en la línea de comandos (visible con ps).
+type rcServer struct {
+	cmd    *exec.Cmd
+	url    string
+	user   string
+	pass   string
+	output bytes.Buffer
+	exited chan struct{}
+}
+
+// secretKeyRe identifica opciones de rclone que contienen credenciales
+var secretKeyRe = regexp.MustCompile(`(?i)(pass|secret|token|2fa|service_account_credentials)`)
+
+// IsSecretOption indica si la opción de configuración lleva una credencial
+func IsSecretOption(key string) bool {
+	return secretKeyRe.MatchString(key)
+}
+
+// secretValues devuelve los valores sensibles de un mapa de opciones
+func secretValues(opts map[string]string) []string {
+	var out []string
+	for k, v := range opts {
+		if IsSecretOption(k) && v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func randomToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// withEnv añade variables de entorno a un comando ya preparado
+func withEnv(cmd *exec.Cmd, kv ...string) *exec.Cmd {
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, kv...)
+	return cmd
+}
+
+func startRC() (*rcServer, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("error reservando puerto rc: %v", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	s := &rcServer{
+		url:    "http://" + addr + "/",
+		user:   randomToken(),
+		pass:   randomToken(),
+		exited: make(chan struct{}),
+	}
+	// Usuario y clave del rc por entorno, no por argumentos
+	s.cmd = withEnv(command("rcd", "--rc-addr", addr),
+		"RCLONE_RC_USER="+s.user, "RCLONE_RC_PASS="+s.pass)
+	s.cmd.Stdout = &s.output
+	s.cmd.Stderr = &s.output
+	if err := s.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error iniciando rclone rcd: %v", err)
+	}
+	go func() {
+		s.cmd.Wait()
+		close(s.exited)
+	}()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		select {
+		case <-s.exited:
+			return nil, fmt.Errorf("rclone rcd terminó: %s", strings.TrimSpace(s.output.String()))
+		default:
+		}
+		if err := s.call("core/version", map[string]any{}, nil); err == nil {
+			return s, nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	s.stop()
+	return nil, fmt.Errorf("rclone rcd no respondió a tiempo")
+}
+
+// call invoca un método del API rc con parámetros JSON
+func (s *rcServer) call(method string, in map[string]any, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.user, s.pass)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 2 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		var rcErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &rcErr) == nil && rcErr.Error != "" {
+			return fmt.Errorf("%s", rcErr.Error)
+		}
+		return fmt.Errorf("rc %s: %s", method, resp.Status)
+	}
+	if out != nil {
+		return json.Unmarshal(data, out)
+	}
+	return nil
+}
+
+func (s *rcServer) stop() {
+	if s.cmd.Process != nil {
+		s.cmd.Process.Kill()
+	}
+	<-s.exited
+}
+
+// withRC arranca un rcd temporal, ejecuta fn y lo detiene
+func withRC(fn func(rc *rcServer) error) error {
+	rc, err := startRC()
+	if err != nil {
+		return err
+	}
+	defer rc.stop()
+	return fn(rc)
+}
@@ -0,0 +1,57 @@
+package rclone
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSecretsStayOutOfArgv(t *testing.T) {
+	argvLog := useFakeRclone(t)
+	const secret = "n0-3n-el-ps-Sup3rSecreto"
+
+	if err := CreateConfigWithOpts("nube", "webdav", map[string]string{
+		"url":  "https://dav.example.com",
+		"user": "ana",
+		"pass": secret,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateConfig("nube", map[string]string{"pass": secret}); err != nil {
+		t.Fatal(err)
+	}
+	creds := S3Credentials{Provider: "Minio", AccessKey: "ana", SecretKey: secret, Endpoint: "http://127.0.0.1:9000"}
+	if _, err := ListBuckets(creds); err != nil {
+		t.Fatal(err)
+	}
+
+	// Los errores del API rc pueden repetir los parámetros: no deben llegar al usuario
+	err := CreateConfigWithOpts("falla", "s3", map[string]string{"secret_access_key": secret})
+	if err == nil {
+		t.Fatal("config/create debía fallar")
+	}
+	if strings.Contains(err.Error(), secret) || !strings.Contains(err.Error(), "***") {
+		t.Fatalf("el error no oculta el secreto: %v", err)
+	}
+	err = UpdateConfig("falla", map[string]string{"pass": secret})
+	if err == nil {
+		t.Fatal("config/update debía fallar")
+	}
+	if strings.Contains(err.Error(), secret) || !strings.Contains(err.Error(), "***") {
+		t.Fatalf("el error no oculta el secreto: %v", err)
+	}
+
+	argvs := recordedArgv(t, argvLog)
+	rcd := 0
+	for _, argv := range argvs {
+		line := strings.Join(argv, " ")
+		if strings.Contains(line, secret) {
+			t.Fatalf("el secreto aparece en la línea de comandos: %s", line)
+		}
+		if len(argv) > 1 && argv[1] == "rcd" {
+			rcd++
+		}
+	}
+	if rcd != 4 {
+		t.Fatalf("se lanzaron %d rclone rcd, esperaba 4 (uno por operación de configuración)", rcd)
+	}
+}
@@ -0,0 +1,142 @@
+package rclone
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/redact"
+)
+
+// Opciones de S3 que ofrece el asistente ("" = valor por defecto del proveedor)
+var (
+	S3StorageClasses = []string{"", "STANDARD", "STANDARD_IA", "ONEZONE_IA", "INTELLIGENT_TIERING", "GLACIER_IR", "REDUCED_REDUNDANCY"}
+	S3Encryptions    = []string{"", "AES256", "aws:kms"}
+	S3ACLs           = []string{"private", "public-read", "authenticated-read", "bucket-owner-full-control"}
+)
+
+// S3HTTPClient se usa para detectar la región del bucket
+var S3HTTPClient = &http.Client{Timeout: 15 * time.Second}
+
+// S3Credentials son los datos de acceso antes de crear el remote
+type S3Credentials struct {
+	Provider  string
+	AccessKey string
+	SecretKey string
+	Endpoint  string
+	Region    string
+}
+
+// S3Options son las opciones de almacenamiento del remote
+type S3Options struct {
+	StorageClass string
+	Encryption   string
+	ACL          string
+}
+
+// s3Command prepara rclone contra un remote S3 al vuelo (":s3:").
+// Las credenciales viajan por entorno, nunca como argumentos.
+func (c S3Credentials) s3Command(args ...string) *exec.Cmd {
+	env := []string{
+		"RCLONE_S3_PROVIDER=" + c.Provider,
+		"RCLONE_S3_ENV_AUTH=false",
+		"RCLONE_S3_ACCESS_KEY_ID=" + c.AccessKey,
+		"RCLONE_S3_SECRET_ACCESS_KEY=" + c.SecretKey,
+	}
+	if c.Endpoint != "" {
+		env = append(env, "RCLONE_S3_ENDPOINT="+c.Endpoint)
+	}
+	if c.Region != "" {
+		env = append(env, "RCLONE_S3_REGION="+c.Region)
+	}
+	return withEnv(command(args...), env...)
+}
+
+type lsjsonItem struct {
+	Name  string `json:"Name"`
+	IsDir bool   `json:"IsDir"`
+	ID    string `json:"ID"`
+}
+
+func (c S3Credentials) listDirs(path string) ([]string, error) {
+	// Solo stdout es JSON; los avisos de rclone van a stderr
+	out, err := c.s3Command("lsjson", ":s3:"+path, "--dirs-only").Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s", redact.Secrets(stderrOf(err), c.SecretKey))
+	}
+	var items []lsjsonItem
+	if err := json.Unmarshal(out, &items); err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, it := range items {
+		names = append(names, it.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ListBuckets comprueba las credenciales listando los buckets
+func ListBuckets(c S3Credentials) ([]string, error) {
+	return c.listDirs("")
+}
+
+// ListPrefixes lista las "carpetas" dentro de bucket/prefijo
+func ListPrefixes(c S3Credentials, bucket, prefix string) ([]string, error) {
+	return c.listDirs(strings.TrimSuffix(bucket+"/"+strings.Trim(prefix, "/"), "/"))
+}
+
+// DetectBucketRegion pregunta al servidor la región del bucket. S3 la
+// devuelve en la cabecera x-amz-bucket-region incluso sin autenticar.
+func DetectBucketRegion(c S3Credentials, bucket string) (string, error) {
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3.amazonaws.com"
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	req, err := http.NewRequest(http.MethodHead, strings.TrimSuffix(endpoint, "/")+"/"+bucket, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := S3HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if region := resp.Header.Get("X-Amz-Bucket-Region"); region != "" {
+		return region, nil
+	}
+	return "", fmt.Errorf("el servidor no informa de la region")
+}
+
+// CreateS3 crea el remote S3 con la región y opciones elegidas
+func CreateS3(name string, c S3Credentials, o S3Options) error {
+	opts := map[string]string{
+		"provider":          c.Provider,
+		"env_auth":          "false",
+		"access_key_id":     c.AccessKey,
+		"secret_access_key": c.SecretKey,
+	}
+	if c.Endpoint != "" {
+		opts["endpoint"] = c.Endpoint
+	}
+	if c.Region != "" {
+		opts["region"] = c.Region
+	}
+	if o.StorageClass != "" {
+		opts["storage_class"] = o.StorageClass
+	}
+	if o.Encryption != "" {
+		opts["server_side_encryption"] = o.Encryption
+	}
+	if o.ACL != "" {
+		opts["acl"] = o.ACL
+	}
+	return CreateConfigWithOpts(name, "s3", opts)
+}
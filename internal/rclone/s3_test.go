@@ -0,0 +1,112 @@
+package rclone
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListDirsIgnoresStderr(t *testing.T) {
+	useFakeRclone(t)
+	const secret = "s3-Sup3rSecreto"
+	c := S3Credentials{Provider: "Minio", AccessKey: "ana", SecretKey: secret}
+
+	// El aviso en stderr no debe mezclarse con el JSON
+	if _, err := ListBuckets(c); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ListPrefixes(c, "falla", "")
+	if err == nil {
+		t.Fatal("lsjson debía fallar")
+	}
+	if !strings.Contains(err.Error(), "firma no valida") || strings.Contains(err.Error(), "NOTICE") {
+		t.Fatalf("el error no es la última línea de stderr: %v", err)
+	}
+	if strings.Contains(err.Error(), secret) {
+		t.Fatalf("el error muestra la clave secreta: %v", err)
+	}
+}
+
+// startServeS3 lanza `rclone serve s3` sobre dir y devuelve su endpoint
+func startServeS3(t *testing.T, dir, accessKey, secretKey string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	cmd := exec.Command("rclone", "serve", "s3", "--addr", addr, "--auth-key", accessKey+","+secretKey, dir)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	for deadline := time.Now().Add(15 * time.Second); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		if conn, err := net.Dial("tcp", addr); err == nil {
+			conn.Close()
+			return "http://" + addr
+		}
+	}
+	t.Fatal("rclone serve s3 no arrancó")
+	return ""
+}
+
+func TestS3AgainstServeS3(t *testing.T) {
+	if _, err := exec.LookPath("rclone"); err != nil {
+		t.Skip("rclone no disponible")
+	}
+	t.Setenv("RCLONE_CONFIG", filepath.Join(t.TempDir(), "rclone.conf"))
+
+	dir := t.TempDir()
+	for _, d := range []string{"fotos/2024", "fotos/2025", "copias"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Los prefijos de S3 existen por sus objetos
+	for _, f := range []string{"fotos/2024/a.jpg", "fotos/2025/b.jpg", "copias/c.tar"} {
+		if err := os.WriteFile(filepath.Join(dir, f), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const access, secret = "ana", "s3-Sup3rSecreto"
+	endpoint := startServeS3(t, dir, access, secret)
+	c := S3Credentials{Provider: "Rclone", AccessKey: access, SecretKey: secret, Endpoint: endpoint}
+
+	buckets, err := ListBuckets(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"copias", "fotos"}; !reflect.DeepEqual(buckets, want) {
+		t.Fatalf("ListBuckets = %v, esperaba %v", buckets, want)
+	}
+	prefixes, err := ListPrefixes(c, "fotos", "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"2024", "2025"}; !reflect.DeepEqual(prefixes, want) {
+		t.Fatalf("ListPrefixes = %v, esperaba %v", prefixes, want)
+	}
+
+	wrong := c
+	wrong.SecretKey = "otra-" + strconv.Itoa(os.Getpid())
+	_, err = ListBuckets(wrong)
+	if err == nil {
+		t.Fatal("ListBuckets debía fallar con una clave incorrecta")
+	}
+	if strings.Contains(err.Error(), wrong.SecretKey) {
+		t.Fatalf("el error muestra la clave secreta: %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package rclone
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/settings"
+)
+
+// Backends que aceptan una cuenta de servicio de Google
+const (
+	BackendDrive = "drive"
+	BackendGCS   = "google cloud storage"
+)
+
+// ServiceAccountKey son los campos que usamos de la clave JSON de Google
+type ServiceAccountKey struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// ServiceAccountConfig describe un remote autenticado con cuenta de servicio
+type ServiceAccountConfig struct {
+	Name        string
+	Backend     string // BackendDrive o BackendGCS
+	Key         []byte // Contenido del JSON importado
+	Impersonate string // Solo Drive: usuario del dominio en cuyo nombre se actúa
+}
+
+// ParseServiceAccountKey valida que el archivo es una clave de cuenta de
+// servicio completa antes de copiarla
+func ParseServiceAccountKey(data []byte) (*ServiceAccountKey, error) {
+	var key ServiceAccountKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("el archivo no es JSON valido: %v", err)
+	}
+	if key.Type != "service_account" {
+		return nil, fmt.Errorf("no es una clave de cuenta de servicio (type = %q)", key.Type)
+	}
+	if key.ClientEmail == "" || key.PrivateKeyID == "" || key.TokenURI == "" {
+		return nil, fmt.Errorf("a la clave le faltan client_email, private_key_id o token_uri")
+	}
+	block, _ := pem.Decode([]byte(key.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("private_key no contiene una clave PEM")
+	}
+	if _, err := x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+		return nil, fmt.Errorf("private_key no valida: %v", err)
+	}
+	return &key, nil
+}
+
+// credentialsDir es donde guardamos las claves importadas
+func credentialsDir() string {
+	return filepath.Join(settings.ConfigDir(), "credentials")
+}
+
+// importServiceAccountKey copia la clave al directorio de la app (0600)
+func importServiceAccountKey(remoteName string, data []byte) (string, error) {
+	dir := credentialsDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, MountDirName(remoteName)+".json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+	// WriteFile no cambia los permisos si el archivo ya existía
+	return path, os.Chmod(path, 0600)
+}
+
+// CreateServiceAccountRemote importa la clave y crea el remote que la usa
+func CreateServiceAccountRemote(c ServiceAccountConfig) error {
+	key, err := ParseServiceAccountKey(c.Key)
+	if err != nil {
+		return err
+	}
+	path, err := importServiceAccountKey(c.Name, c.Key)
+	if err != nil {
+		return fmt.Errorf("no se pudo guardar la clave: %v", err)
+	}
+
+	opts := map[string]string{"service_account_file": path}
+	switch c.Backend {
+	case BackendDrive:
+		opts["scope"] = "drive"
+		if c.Impersonate != "" {
+			opts["impersonate"] = c.Impersonate
+		}
+	case BackendGCS:
+		opts["bucket_policy_only"] = "true"
+		if key.ProjectID != "" {
+			opts["project_number"] = key.ProjectID
+		}
+	default:
+		return fmt.Errorf("backend no admitido: %s", c.Backend)
+	}
+
+	if err := CreateConfigWithOpts(c.Name, c.Backend, opts); err != nil {
+		os.Remove(path)
+		return err
+	}
+	return nil
+}
+
+// removeImportedKey borra la clave importada de un remote si ningún otro
+// remote (por ejemplo, una unidad compartida clonada) la sigue usando
+func removeImportedKey(remoteName string, dump map[string]map[string]string) {
+	path := dump[remoteName]["service_account_file"]
+	if path == "" || !strings.HasPrefix(path, credentialsDir()+string(os.PathSeparator)) {
+		return
+	}
+	for name, conf := range dump {
+		if name != remoteName && conf["service_account_file"] == path {
+			return
+		}
+	}
+	os.Remove(path)
+}
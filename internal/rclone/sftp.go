@@ -0,0 +1,63 @@
+package rclone
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Métodos de autenticación del asistente SFTP
+const (
+	SFTPAuthPassword = "password"
+	SFTPAuthKey      = "key"
+	SFTPAuthAgent    = "agent"
+)
+
+// SFTPConfig son los datos del asistente SFTP
+type SFTPConfig struct {
+	Name           string
+	Host           string
+	Port           int
+	User           string
+	Auth           string
+	Password       string
+	KeyFile        string
+	KeyPassphrase  string
+	KnownHostsFile string
+}
+
+// CreateSFTP crea un remote sftp que verifica la clave del host con known_hosts
+func CreateSFTP(c SFTPConfig) error {
+	if c.Host == "" || c.User == "" {
+		return fmt.Errorf("faltan el servidor o el usuario")
+	}
+	opts := map[string]string{
+		"host":             c.Host,
+		"user":             c.User,
+		"known_hosts_file": c.KnownHostsFile,
+	}
+	if c.Port != 0 && c.Port != 22 {
+		opts["port"] = strconv.Itoa(c.Port)
+	}
+
+	switch c.Auth {
+	case SFTPAuthPassword:
+		opts["pass"] = c.Password
+	case SFTPAuthKey:
+		if c.KeyFile == "" {
+			return fmt.Errorf("elige el archivo de clave privada")
+		}
+		opts["key_file"] = c.KeyFile
+		if c.KeyPassphrase != "" {
+			opts["key_file_pass"] = c.KeyPassphrase
+		}
+	case SFTPAuthAgent:
+		opts["key_use_agent"] = "true"
+		if c.KeyFile != "" {
+			// Con key_use_agent, key_file indica qué clave del agente usar
+			opts["key_file"] = c.KeyFile
+		}
+	default:
+		return fmt.Errorf("metodo de autenticacion desconocido")
+	}
+	return CreateConfigWithOpts(c.Name, "sftp", opts)
+}
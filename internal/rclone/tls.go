@@ -0,0 +1,192 @@
+package rclone
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/anabasasoft/cloudmount-wizard/internal/settings"
+)
+
+// helperExe es este mismo ejecutable; las unidades systemd lo invocan para
+// las comprobaciones que rclone no sabe hacer (certificado fijado)
+var helperExe string
+
+// SetHelperExecutable registra la ruta del ejecutable de la app
+func SetHelperExecutable(path string) {
+	helperExe = path
+}
+
+// TLSEndpoint devuelve la URL https del servidor del remote ("" si no aplica)
+func TLSEndpoint(remoteName string) string {
+	conf, err := GetRemoteConfig(remoteName)
+	if err != nil {
+		return ""
+	}
+	endpoint := conf["url"]
+	if conf["type"] == "s3" {
+		endpoint = conf["endpoint"]
+		if endpoint != "" && !strings.Contains(endpoint, "://") {
+			endpoint = "https://" + endpoint
+		}
+	}
+	if !strings.HasPrefix(endpoint, "https://") {
+		return ""
+	}
+	return endpoint
+}
+
+// CertFingerprint devuelve la huella SHA256 en el formato habitual AA:BB:...
+func CertFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// FetchServerCerts se conecta sin verificar y devuelve la cadena que presenta
+// el servidor, para enseñarla al usuario antes de confiar en ella
+func FetchServerCerts(endpoint string, opts settings.RemoteOptions) ([]*x509.Certificate, error) {
+	return dialTLS(endpoint, opts, false)
+}
+
+func dialTLS(endpoint string, opts settings.RemoteOptions, verify bool) ([]*x509.Certificate, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("URL no valida: %s", endpoint)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "443")
+	}
+
+	cfg := &tls.Config{
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: !verify, // Sin verificar solo para leer y enseñar el certificado
+	}
+	if verify && opts.CACert != "" {
+		caPEM, err := os.ReadFile(opts.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo leer la CA: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("%s no contiene certificados PEM", opts.CACert)
+		}
+		cfg.RootCAs = pool
+	}
+	if opts.ClientCert != "" && opts.ClientKey != "" {
+		pair, err := tls.LoadX509KeyPair(opts.ClientCert, opts.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("certificado cliente no valido: %v", err)
+		}
+		cfg.Certificates = []tls.Certificate{pair}
+	}
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 15 * time.Second}, "tcp", addr, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error TLS con %s: %v", addr, err)
+	}
+	defer conn.Close()
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("%s no presento ningun certificado", addr)
+	}
+	return certs, nil
+}
+
+// PinServerCerts guarda la cadena aceptada como CA propia del remote y
+// devuelve la ruta del archivo PEM. rclone solo admite un --ca-cert: si el
+// remote ya tenía una CA (caFile), el archivo las lleva juntas.
+func PinServerCerts(remoteName string, certs []*x509.Certificate, caFile string) (string, error) {
+	dir := filepath.Join(settings.ConfigDir(), "tls")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, MountDirName(remoteName)+"-pinned.pem")
+	var data []byte
+	if caFile != "" {
+		existing, err := os.ReadFile(caFile)
+		if err != nil {
+			return "", fmt.Errorf("no se pudo leer la CA %s: %v", caFile, err)
+		}
+		data = existing
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			data = append(data, '\n')
+		}
+	}
+	for _, c := range certs {
+		block := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})
+		if !bytes.Contains(data, block) {
+			data = append(data, block...)
+		}
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// VerifyServerTLS comprueba el certificado del servidor antes de montar:
+// contra la huella fijada si la hay, o contra la CA configurada. Así el
+// error TLS se ve en la app en lugar de quedar enterrado en el log de rclone.
+// Sin ajustes TLS propios no hace nada: la conexión directa se saltaría el
+// proxy (HTTPS_PROXY) que rclone sí usa.
+func VerifyServerTLS(remoteName string) error {
+	endpoint := TLSEndpoint(remoteName)
+	if endpoint == "" {
+		return nil
+	}
+	opts := settings.GetOptions(remoteName)
+	if opts.PinnedCert == "" && opts.CACert == "" && opts.ClientCert == "" {
+		return nil
+	}
+	if opts.PinnedCert != "" {
+		certs, err := FetchServerCerts(endpoint, opts)
+		if err != nil {
+			return err
+		}
+		if got := CertFingerprint(certs[0]); got != opts.PinnedCert {
+			return fmt.Errorf("el certificado de %s ha cambiado (%s); no coincide con el fijado", endpoint, got)
+		}
+		return nil
+	}
+	_, err := dialTLS(endpoint, opts, true)
+	return err
+}
+
+// IsTLSError indica si un error de montaje se debe al certificado del servidor
+func IsTLSError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "x509:") || strings.Contains(msg, "certificate signed by unknown authority") ||
+		strings.Contains(msg, "tls: ")
+}
+
+// tlsFlags traduce los ajustes TLS del remote a flags de rclone
+func tlsFlags(opts settings.RemoteOptions) []string {
+	var args []string
+	if opts.CACert != "" {
+		args = append(args, "--ca-cert", opts.CACert)
+	}
+	if opts.ClientCert != "" {
+		args = append(args, "--client-cert", opts.ClientCert)
+	}
+	if opts.ClientKey != "" {
+		args = append(args, "--client-key", opts.ClientKey)
+	}
+	return args
+}
@@ -0,0 +1,95 @@
+package rclone
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// selfSigned genera una CA autofirmada de prueba
+func selfSigned(t *testing.T, cn string) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// pemCerts decodifica todos los certificados de un archivo PEM
+func pemCerts(t *testing.T, path string) []*x509.Certificate {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		certs = append(certs, c)
+	}
+}
+
+func TestPinServerCertsKeepsExistingCA(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	ca := selfSigned(t, "CA de la empresa")
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "empresa.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := PinServerCerts("nube", []*x509.Certificate{server.Certificate()}, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := pemCerts(t, path)
+	if len(got) != 2 || !got[0].Equal(ca) || !got[1].Equal(server.Certificate()) {
+		t.Fatalf("el PEM debía llevar la CA existente y la cadena aceptada, tiene %d certificados", len(got))
+	}
+
+	// Volver a confiar con el propio archivo como CA no duplica certificados
+	path, err = PinServerCerts("nube", []*x509.Certificate{server.Certificate()}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := pemCerts(t, path); len(got) != 2 {
+		t.Fatalf("el PEM tiene %d certificados tras confiar otra vez, esperaba 2", len(got))
+	}
+}
@@ -0,0 +1,136 @@
+package rclone
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// authErrorRe reconoce en el log de rclone los fallos de token que solo se
+// arreglan volviendo a autorizar (refresh token caducado o revocado)
+var authErrorRe = regexp.MustCompile(`(?i)(invalid_grant|couldn't fetch token|failed to refresh token|token has been expired or revoked|expired_access_token|invalid_access_token|InvalidAuthenticationToken|AADSTS700082|unauthorized_client)`)
+
+// reconnectMarker se escribe en el log al reconectar: los errores
+// anteriores a esta línea ya no cuentan
+const reconnectMarker = "--- CloudMount: reconectado ---"
+
+// logTailSize es cuánto del final del log se revisa
+const logTailSize = 64 * 1024
+
+// TokenStatus es el estado del token OAuth de un remote
+type TokenStatus struct {
+	Expiry      time.Time
+	NeedsReauth bool
+	Reason      string
+}
+
+// CheckToken revisa el token del volcado de configuración y el log del
+// remote. conf es dump[name]; los remotes sin OAuth devuelven estado vacío.
+func CheckToken(name string, conf map[string]string) TokenStatus {
+	var st TokenStatus
+	if conf["token"] == "" {
+		return st
+	}
+	var tok struct {
+		oauthToken
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.Unmarshal([]byte(conf["token"]), &tok); err != nil {
+		st.NeedsReauth = true
+		st.Reason = "el token guardado no es valido"
+		return st
+	}
+	st.Expiry = tok.Expiry
+
+	// Sin refresh token, al caducar el access token no hay forma de renovarlo
+	if tok.RefreshToken == "" && !tok.Expiry.IsZero() && time.Now().After(tok.Expiry) {
+		st.NeedsReauth = true
+		st.Reason = "el token caduco el " + tok.Expiry.Format("2006-01-02 15:04")
+		return st
+	}
+
+	if line := lastAuthError(GetLogFilePath(name)); line != "" {
+		st.NeedsReauth = true
+		st.Reason = line
+	}
+	return st
+}
+
+// lastAuthError devuelve el último error de autorización del log posterior
+// a la última reconexión ("" si no hay)
+func lastAuthError(logPath string) string {
+	f, err := os.Open(logPath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil && info.Size() > logTailSize {
+		f.Seek(-logTailSize, io.SeekEnd)
+	}
+	data, _ := io.ReadAll(f)
+	text := string(data)
+	if i := strings.LastIndex(text, reconnectMarker); i >= 0 {
+		text = text[i:]
+	}
+	found := ""
+	for _, line := range strings.Split(text, "\n") {
+		if authErrorRe.MatchString(line) {
+			found = strings.TrimSpace(line)
+		}
+	}
+	return found
+}
+
+// reconnectKeepKeys son opciones que `config reconnect --auto-confirm`
+// rehace con el valor por defecto (OneDrive vuelve a la unidad personal)
+var reconnectKeepKeys = []string{"drive_id", "drive_type"}
+
+// Reconnect vuelve a autorizar el remote con `rclone config reconnect`.
+// Las opciones de reconnectKeepKeys se guardan antes y se restauran después
+// para que el remote siga apuntando a la misma unidad. onURL recibe la URL
+// de autorización.
+func Reconnect(ctx context.Context, name string, onURL func(string)) error {
+	conf, err := GetRemoteConfig(name)
+	if err != nil {
+		return err
+	}
+	keep := make(map[string]string)
+	for _, k := range reconnectKeepKeys {
+		if conf[k] != "" {
+			keep[k] = conf[k]
+		}
+	}
+	if _, err := runOAuthCommand(ctx, onURL, "config", "reconnect", name+":", "--auto-confirm", "--auth-no-open-browser"); err != nil {
+		return err
+	}
+	if len(keep) > 0 {
+		if err := UpdateConfig(name, keep); err != nil {
+			return fmt.Errorf("reconectado, pero no se pudo restaurar la unidad: %v", err)
+		}
+	}
+	markReconnected(name)
+	return nil
+}
+
+// ReconnectWithToken guarda un token obtenido con `rclone authorize` en otra máquina
+func ReconnectWithToken(name, token string) error {
+	if err := UpdateConfig(name, map[string]string{"token": token}); err != nil {
+		return err
+	}
+	markReconnected(name)
+	return nil
+}
+
+func markReconnected(name string) {
+	f, err := os.OpenFile(GetLogFilePath(name), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return // Sin log no hay errores viejos que ocultar
+	}
+	defer f.Close()
+	fmt.Fprintf(f, "%s %s\n", time.Now().Format("2006/01/02 15:04:05"), reconnectMarker)
+}
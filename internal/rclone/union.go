@@ -0,0 +1,122 @@
+package rclone
+
+import (
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Políticas del backend union por categoría (el primer valor es el de rclone por defecto)
+var (
+	UnionActionPolicies = []string{"epall", "epff", "all", "ff", "newest"}
+	UnionCreatePolicies = []string{"epmfs", "eplfs", "eplus", "epff", "mfs", "lfs", "lus", "ff", "rand"}
+	UnionSearchPolicies = []string{"ff", "epff", "newest", "mfs", "lfs"}
+)
+
+// joinSpaceSep une una lista con la sintaxis de rclone para listas separadas
+// por espacios (los elementos con espacios van entre comillas)
+func joinSpaceSep(items []string) string {
+	out := make([]string, len(items))
+	for i, item := range items {
+		if strings.ContainsAny(item, " \"") {
+			item = `"` + strings.ReplaceAll(item, `"`, `""`) + `"`
+		}
+		out[i] = item
+	}
+	return strings.Join(out, " ")
+}
+
+func splitSpaceSep(s string) []string {
+	r := csv.NewReader(strings.NewReader(s))
+	r.Comma = ' '
+	fields, err := r.Read()
+	if err != nil {
+		return strings.Fields(s)
+	}
+	var out []string
+	for _, f := range fields {
+		if f != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// CreateUnion crea un remote union que fusiona varias rutas "remote:carpeta"
+func CreateUnion(name string, upstreams []string, action, create, search string) error {
+	if len(upstreams) < 2 {
+		return fmt.Errorf("elige al menos dos unidades")
+	}
+	return CreateConfigWithOpts(name, "union", map[string]string{
+		"upstreams":     joinSpaceSep(upstreams),
+		"action_policy": action,
+		"create_policy": create,
+		"search_policy": search,
+	})
+}
+
+// CreateCombine crea un remote combine: cada carpeta apunta a una ruta "remote:carpeta"
+func CreateCombine(name string, dirs map[string]string) error {
+	if len(dirs) < 2 {
+		return fmt.Errorf("elige al menos dos unidades")
+	}
+	var keys []string
+	for dir := range dirs {
+		if dir == "" || strings.ContainsAny(dir, "/=") {
+			return fmt.Errorf("nombre de carpeta no valido: '%s'", dir)
+		}
+		keys = append(keys, dir)
+	}
+	sort.Strings(keys)
+
+	var upstreams []string
+	for _, dir := range keys {
+		upstreams = append(upstreams, dir+"="+dirs[dir])
+	}
+	return CreateConfigWithOpts(name, "combine", map[string]string{
+		"upstreams": joinSpaceSep(upstreams),
+	})
+}
+
+// MemberRemotes devuelve los remotes que forman un union o combine
+func MemberRemotes(conf map[string]string) []string {
+	if conf["type"] != "union" && conf["type"] != "combine" {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var members []string
+	for _, up := range splitSpaceSep(conf["upstreams"]) {
+		if conf["type"] == "combine" {
+			if i := strings.Index(up, "="); i >= 0 {
+				up = up[i+1:]
+			}
+		}
+		if r := RemoteOf(up); r != "" && !seen[r] {
+			seen[r] = true
+			members = append(members, r)
+		}
+	}
+	return members
+}
+
+// AggregateQuota suma la cuota de todos los miembros que la informan
+func AggregateQuota(members []string) (*Quota, error) {
+	var total Quota
+	found := false
+	for _, m := range members {
+		q, err := GetQuota(m)
+		if err != nil {
+			continue
+		}
+		found = true
+		total.Total += q.Total
+		total.Used += q.Used
+		total.Free += q.Free
+		total.Trash += q.Trash
+	}
+	if !found {
+		return nil, fmt.Errorf("ningun miembro informa de su cuota")
+	}
+	return &total, nil
+}
@@ -0,0 +1,14 @@
+package redact
+
+import "strings"
+
+// Secrets sustituye por "***" cada secreto que aparezca en s. Se usa antes
+// de mostrar o registrar la salida de programas que recibieron credenciales.
+func Secrets(s string, secrets ...string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, "***")
+		}
+	}
+	return s
+}
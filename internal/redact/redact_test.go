@@ -0,0 +1,21 @@
+package redact
+
+import "testing"
+
+func TestSecrets(t *testing.T) {
+	tests := []struct {
+		in      string
+		secrets []string
+		want    string
+	}{
+		{"login failed for pepe with hunter2", []string{"hunter2"}, "login failed for pepe with ***"},
+		{"a=k1 b=k2 a=k1", []string{"k1", "k2"}, "a=*** b=*** a=***"},
+		{"sin secretos", []string{""}, "sin secretos"},
+		{"sin secretos", nil, "sin secretos"},
+	}
+	for _, tt := range tests {
+		if got := Secrets(tt.in, tt.secrets...); got != tt.want {
+			t.Errorf("Secrets(%q, %q) = %q, esperaba %q", tt.in, tt.secrets, got, tt.want)
+		}
+	}
+}
@@ -13,6 +13,22 @@ type RemoteOptions struct {
 	BwLimit      string `json:"bw_limit"`   // Ej: "2M"
 	MountOnStart bool   `json:"mount_on_start"`
 	RootFolderID string `json:"root_folder_id"`
+	Provider     string `json:"provider"`    // "" = backend de rclone; "mega" = MEGAcmd
+	RemotePath   string `json:"remote_path"` // Subcarpeta a montar (Ej: "bucket/prefijo")
+	MegaPath     string `json:"mega_path"`   // Carpeta de MEGA servida por WebDAV ("" = raíz)
+
+	// Google Drive: formatos de Docs/Sheets/Slides y papelera
+	DriveExportFormats    string `json:"drive_export_formats"` // Vacío = formatos de LibreOffice
+	DriveImportFormats    string `json:"drive_import_formats"` // Ej: "odt,ods" (convierte al subir)
+	DriveSkipGdocs        bool   `json:"drive_skip_gdocs"`
+	DriveAcknowledgeAbuse bool   `json:"drive_acknowledge_abuse"`
+	DriveUseTrash         *bool  `json:"drive_use_trash,omitempty"` // nil = usar papelera (por defecto en rclone)
+
+	// TLS para servidores propios (WebDAV, S3...)
+	CACert     string `json:"ca_cert"`     // Bundle de CA (--ca-cert)
+	ClientCert string `json:"client_cert"` // Certificado cliente mTLS (--client-cert)
+	ClientKey  string `json:"client_key"`  // Clave del certificado cliente (--client-key)
+	PinnedCert string `json:"pinned_cert"` // Huella SHA256 aceptada la primera vez (TOFU)
 }
 
 type AppConfig struct {
@@ -47,16 +63,34 @@ func SetOptions(remoteName string, opts RemoteOptions) error {
 	return save()
 }
 
+// DeleteOptions olvida los ajustes de un remote borrado: otro con el
+// mismo nombre no debe heredarlos
+func DeleteOptions(remoteName string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if _, ok := current.Remotes[remoteName]; !ok {
+		return nil
+	}
+	delete(current.Remotes, remoteName)
+	return save()
+}
+
 // Helpers individuales para compatibilidad (opcional, pero útil)
 func GetReadOnly(remoteName string) bool { return GetOptions(remoteName).ReadOnly }
 
 // --- PERSISTENCIA ---
 
-func getConfigPath() string {
+// ConfigDir devuelve (y crea) el directorio de configuración de la app
+func ConfigDir() string {
 	configDir, _ := os.UserConfigDir()
 	dir := filepath.Join(configDir, "cloudmount")
 	os.MkdirAll(dir, 0755)
-	return filepath.Join(dir, "settings.json")
+	return dir
+}
+
+func getConfigPath() string {
+	return filepath.Join(ConfigDir(), "settings.json")
 }
 
 func load() {
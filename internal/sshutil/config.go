@@ -0,0 +1,140 @@
+package sshutil
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Host es una entrada de ~/.ssh/config lista para rellenar el asistente SFTP
+type Host struct {
+	Alias        string
+	HostName     string
+	User         string
+	Port         string
+	IdentityFile string
+}
+
+// UserConfigPath devuelve la ruta de ~/.ssh/config
+func UserConfigPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ssh", "config")
+}
+
+// LoadUserConfig lee los hosts de ~/.ssh/config (vacío si no existe)
+func LoadUserConfig() []Host {
+	hosts, _ := ParseConfig(UserConfigPath())
+	return hosts
+}
+
+// ParseConfig extrae los bloques Host con alias concretos (sin comodines).
+// Los valores de bloques genéricos ("Host *") completan lo que falte,
+// igual que ssh: gana el primer valor encontrado para cada opción.
+func ParseConfig(path string) ([]Host, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	type block struct {
+		patterns []string
+		opts     map[string]string
+	}
+	var blocks []*block
+	var cur *block
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value := splitOption(line)
+		switch strings.ToLower(key) {
+		case "host":
+			cur = &block{patterns: strings.Fields(value), opts: make(map[string]string)}
+			blocks = append(blocks, cur)
+		case "match":
+			// Los bloques Match dependen del contexto; no los interpretamos
+			cur = nil
+		default:
+			if cur != nil {
+				k := strings.ToLower(key)
+				if _, set := cur.opts[k]; !set {
+					cur.opts[k] = value
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var hosts []Host
+	for _, b := range blocks {
+		for _, alias := range b.patterns {
+			if strings.ContainsAny(alias, "*?!") {
+				continue
+			}
+			opts := make(map[string]string)
+			for _, other := range blocks {
+				if !matchesAny(alias, other.patterns) {
+					continue
+				}
+				for k, v := range other.opts {
+					if _, set := opts[k]; !set {
+						opts[k] = v
+					}
+				}
+			}
+			h := Host{
+				Alias:        alias,
+				HostName:     opts["hostname"],
+				User:         opts["user"],
+				Port:         opts["port"],
+				IdentityFile: expandHome(opts["identityfile"]),
+			}
+			if h.HostName == "" {
+				h.HostName = alias
+			}
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts, nil
+}
+
+// splitOption separa "Clave valor" o "Clave=valor"
+func splitOption(line string) (string, string) {
+	i := strings.IndexAny(line, " \t=")
+	if i < 0 {
+		return line, ""
+	}
+	value := strings.TrimLeft(line[i:], " \t=")
+	return line[:i], strings.Trim(strings.TrimSpace(value), `"`)
+}
+
+// matchesAny aplica los patrones de ssh (*, ? y negación con !)
+func matchesAny(host string, patterns []string) bool {
+	matched := false
+	for _, p := range patterns {
+		negate := strings.HasPrefix(p, "!")
+		p = strings.TrimPrefix(p, "!")
+		if ok, _ := filepath.Match(p, host); ok {
+			if negate {
+				return false
+			}
+			matched = true
+		}
+	}
+	return matched
+}
+
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, path[2:])
+	}
+	return path
+}
@@ -0,0 +1,207 @@
+package sshutil
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KeyscanCommand es el binario que obtiene las claves del servidor.
+// Se puede sustituir para apuntar a un servidor SFTP local de pruebas.
+var KeyscanCommand = "ssh-keyscan"
+
+// HostKey es una clave pública de servidor en formato known_hosts
+type HostKey struct {
+	Type string // ssh-ed25519, ecdsa-sha2-nistp256, ssh-rsa...
+	Key  string // Blob en base64
+}
+
+// Fingerprint devuelve la huella SHA256 como la muestra OpenSSH
+func (k HostKey) Fingerprint() string {
+	blob, err := base64.StdEncoding.DecodeString(k.Key)
+	if err != nil {
+		return "(clave no valida)"
+	}
+	sum := sha256.Sum256(blob)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// HostStatus es el resultado de comparar el servidor con known_hosts
+type HostStatus int
+
+const (
+	HostUnknown  HostStatus = iota // No hay entrada: pedir confianza (TOFU)
+	HostKnown                      // Alguna clave coincide
+	HostMismatch                   // Hay entrada pero la clave cambió
+)
+
+// ErrHostNotTrusted indica que el usuario no aceptó la huella del servidor
+var ErrHostNotTrusted = errors.New("el servidor no es de confianza")
+
+// KnownHostsPath devuelve ~/.ssh/known_hosts
+func KnownHostsPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ssh", "known_hosts")
+}
+
+// hostPattern es como escribe OpenSSH el host en known_hosts
+func hostPattern(host string, port int) string {
+	if port == 0 || port == 22 {
+		return host
+	}
+	return fmt.Sprintf("[%s]:%d", host, port)
+}
+
+// matchHost comprueba un campo de hosts de known_hosts (incluidos los hasheados)
+func matchHost(field, pattern string) bool {
+	for _, h := range strings.Split(field, ",") {
+		if strings.HasPrefix(h, "|1|") {
+			parts := strings.Split(h[3:], "|")
+			if len(parts) != 2 {
+				continue
+			}
+			salt, err1 := base64.StdEncoding.DecodeString(parts[0])
+			want, err2 := base64.StdEncoding.DecodeString(parts[1])
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			mac := hmac.New(sha1.New, salt)
+			mac.Write([]byte(pattern))
+			if hmac.Equal(mac.Sum(nil), want) {
+				return true
+			}
+			continue
+		}
+		// Los corchetes de "[host]:puerto" son literales, no clases de caracteres
+		glob := strings.NewReplacer("[", `\[`, "]", `\]`).Replace(h)
+		if ok, _ := filepath.Match(glob, pattern); ok && !strings.HasPrefix(h, "!") {
+			return true
+		}
+	}
+	return false
+}
+
+// LookupKnownHost devuelve las claves registradas para host:port
+func LookupKnownHost(path, host string, port int) ([]HostKey, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	pattern := hostPattern(host, port)
+	var keys []HostKey
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "@") {
+			continue
+		}
+		if matchHost(fields[0], pattern) {
+			keys = append(keys, HostKey{Type: fields[1], Key: fields[2]})
+		}
+	}
+	return keys, scanner.Err()
+}
+
+// ScanHostKeys obtiene las claves que presenta el servidor
+func ScanHostKeys(host string, port int) ([]HostKey, error) {
+	if port == 0 {
+		port = 22
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, KeyscanCommand, "-T", "10", "-p", strconv.Itoa(port), host).Output()
+	if err != nil && len(out) == 0 {
+		return nil, fmt.Errorf("no se pudo contactar con %s: %v", net.JoinHostPort(host, strconv.Itoa(port)), err)
+	}
+	var keys []HostKey
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && !strings.HasPrefix(fields[0], "#") {
+			keys = append(keys, HostKey{Type: fields[1], Key: fields[2]})
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s no devolvio ninguna clave de host", host)
+	}
+	return keys, nil
+}
+
+// CheckHost compara las claves del servidor con known_hosts
+func CheckHost(knownHosts, host string, port int, presented []HostKey) (HostStatus, error) {
+	known, err := LookupKnownHost(knownHosts, host, port)
+	if err != nil {
+		return HostUnknown, err
+	}
+	if len(known) == 0 {
+		return HostUnknown, nil
+	}
+	for _, k := range known {
+		for _, p := range presented {
+			if k.Type == p.Type && k.Key == p.Key {
+				return HostKnown, nil
+			}
+		}
+	}
+	return HostMismatch, nil
+}
+
+// TrustHost añade las claves del servidor a known_hosts (trust-on-first-use)
+func TrustHost(knownHosts, host string, port int, keys []HostKey) error {
+	if err := os.MkdirAll(filepath.Dir(knownHosts), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(knownHosts, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	pattern := hostPattern(host, port)
+	for _, k := range keys {
+		if _, err := fmt.Fprintf(f, "%s %s %s\n", pattern, k.Type, k.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyHost obtiene las claves del servidor y las compara con known_hosts.
+// Si el servidor es nuevo pregunta a confirm (con las huellas a la vista) y,
+// si acepta, guarda las claves; si la clave cambió, devuelve un error.
+func VerifyHost(knownHosts, host string, port int, confirm func([]HostKey) bool) error {
+	keys, err := ScanHostKeys(host, port)
+	if err != nil {
+		return err
+	}
+	status, err := CheckHost(knownHosts, host, port, keys)
+	if err != nil {
+		return err
+	}
+	switch status {
+	case HostKnown:
+		return nil
+	case HostMismatch:
+		return fmt.Errorf("la clave de %s NO coincide con la de known_hosts.\nPodria ser un ataque; revisa el servidor antes de continuar", host)
+	}
+	if !confirm(keys) {
+		return ErrHostNotTrusted
+	}
+	return TrustHost(knownHosts, host, port, keys)
+}
@@ -0,0 +1,218 @@
+package sshutil
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	testUser = "ana"
+	testPass = "clave-de-prueba"
+)
+
+// testServer es un servidor SSH en proceso con el subsistema SFTP. La clave
+// de host se puede cambiar en caliente para simular un servidor suplantado.
+type testServer struct {
+	ln   net.Listener
+	mu   sync.Mutex
+	host ssh.Signer
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testServer{ln: ln}
+	s.rotateKey(t)
+	t.Cleanup(func() { ln.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *testServer) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+// rotateKey genera una clave ed25519 nueva para las próximas conexiones
+func (s *testServer) rotateKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	s.host = signer
+	s.mu.Unlock()
+	return signer.PublicKey()
+}
+
+func (s *testServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		cfg := &ssh.ServerConfig{
+			PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+				if c.User() == testUser && string(pass) == testPass {
+					return nil, nil
+				}
+				return nil, ssh.ErrNoAuth
+			},
+		}
+		cfg.AddHostKey(s.host)
+		s.mu.Unlock()
+		go handleConn(conn, cfg)
+	}
+}
+
+func handleConn(conn net.Conn, cfg *ssh.ServerConfig) {
+	defer conn.Close()
+	_, chans, reqs, err := ssh.NewServerConn(conn, cfg)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for nc := range chans {
+		if nc.ChannelType() != "session" {
+			nc.Reject(ssh.UnknownChannelType, "solo sesiones")
+			continue
+		}
+		ch, requests, err := nc.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer ch.Close()
+			for req := range requests {
+				ok := req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp"
+				req.Reply(ok, nil)
+				if !ok {
+					continue
+				}
+				server, err := sftp.NewServer(ch)
+				if err != nil {
+					return
+				}
+				server.Serve()
+				return
+			}
+		}()
+	}
+}
+
+func fingerprintOf(k ssh.PublicKey) string {
+	return HostKey{Type: k.Type(), Key: base64.StdEncoding.EncodeToString(k.Marshal())}.Fingerprint()
+}
+
+func TestVerifyHostTOFU(t *testing.T) {
+	if _, err := exec.LookPath(KeyscanCommand); err != nil {
+		t.Skip("ssh-keyscan no disponible")
+	}
+	srv := newTestServer(t)
+	first := srv.rotateKey(t)
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+
+	// Primera conexión: se muestra la huella y, al aceptarla, se guarda
+	asked := 0
+	err := VerifyHost(knownHosts, "127.0.0.1", srv.port(), func(keys []HostKey) bool {
+		asked++
+		if len(keys) != 1 || keys[0].Fingerprint() != fingerprintOf(first) {
+			t.Errorf("huellas mostradas %v, esperaba %s", keys, fingerprintOf(first))
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if asked != 1 {
+		t.Fatalf("se preguntó %d veces, esperaba 1", asked)
+	}
+	data, err := os.ReadFile(knownHosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), hostPattern("127.0.0.1", srv.port())+" ssh-ed25519 ") {
+		t.Fatalf("known_hosts inesperado: %q", data)
+	}
+
+	// La entrada escrita la entiende un cliente SSH real y el SFTP funciona
+	callback, err := knownhosts.New(knownHosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := ssh.Dial("tcp", srv.ln.Addr().String(), &ssh.ClientConfig{
+		User:            testUser,
+		Auth:            []ssh.AuthMethod{ssh.Password(testPass)},
+		HostKeyCallback: callback,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc, err := sftp.NewClient(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sc.ReadDir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	sc.Close()
+	client.Close()
+
+	// Segunda conexión con la misma clave: ya es conocida, no se pregunta
+	if err := VerifyHost(knownHosts, "127.0.0.1", srv.port(), func([]HostKey) bool {
+		t.Error("no debía preguntar por un servidor conocido")
+		return false
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// La clave cambia: se rechaza sin preguntar ni tocar known_hosts
+	srv.rotateKey(t)
+	err = VerifyHost(knownHosts, "127.0.0.1", srv.port(), func([]HostKey) bool {
+		t.Error("no debía ofrecer confiar en una clave cambiada")
+		return true
+	})
+	if err == nil || !strings.Contains(err.Error(), "NO coincide") {
+		t.Fatalf("esperaba rechazo por clave cambiada, obtuve %v", err)
+	}
+	after, _ := os.ReadFile(knownHosts)
+	if string(after) != string(data) {
+		t.Fatalf("known_hosts cambió tras el rechazo: %q", after)
+	}
+}
+
+func TestVerifyHostDeclined(t *testing.T) {
+	if _, err := exec.LookPath(KeyscanCommand); err != nil {
+		t.Skip("ssh-keyscan no disponible")
+	}
+	srv := newTestServer(t)
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+
+	err := VerifyHost(knownHosts, "127.0.0.1", srv.port(), func([]HostKey) bool { return false })
+	if err != ErrHostNotTrusted {
+		t.Fatalf("esperaba ErrHostNotTrusted, obtuve %v", err)
+	}
+	if _, err := os.Stat(knownHosts); !os.IsNotExist(err) {
+		t.Fatalf("known_hosts no debía crearse al rechazar la huella")
+	}
+}
@@ -20,23 +20,6 @@ func CheckRclone() bool {
 	return err == nil
 }
 
-// InstallRclone intenta instalar rclone automáticamente
-func InstallRclone() error {
-	switch runtime.GOOS {
-		case "linux", "darwin":
-			// Script oficial de instalación (requiere sudo interno)
-			cmd := exec.Command("sh", "-c", "curl https://rclone.org/install.sh | sudo bash")
-			return cmd.Run()
-		case "windows":
-			if _, err := exec.LookPath("winget"); err == nil {
-				return exec.Command("winget", "install", "Rclone.Rclone").Run()
-			}
-			return openBrowser("https://rclone.org/downloads")
-		default:
-			return openBrowser("https://rclone.org/downloads")
-	}
-}
-
 // --- SECCIÓN MEGACMD (Nueva) ---
 
 // CheckMegaCmd verifica si el comando 'mega-login' existe
@@ -0,0 +1,246 @@
+package system
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// RcloneBaseURL es de donde se descargan las versiones oficiales de rclone.
+// Se puede cambiar para probar el instalador contra un servidor local.
+var RcloneBaseURL = "https://downloads.rclone.org"
+
+// InstallHTTPClient descarga version.txt, SHA256SUMS y el zip
+var InstallHTTPClient = &http.Client{Timeout: 10 * time.Minute}
+
+// InstallProgress recibe el paso actual y cuánto lleva (0-1; -1 si no se sabe)
+type InstallProgress func(status string, fraction float64)
+
+// LocalBinDir es donde se instala rclone sin permisos de root
+func LocalBinDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".local", "bin")
+}
+
+// AddLocalBinToPath añade ~/.local/bin al final del PATH del proceso: las
+// sesiones gráficas no siempre lo incluyen y sin él no encontraríamos
+// rclone. Al final, para no tapar los programas del sistema.
+func AddLocalBinToPath() {
+	dir := LocalBinDir()
+	path := os.Getenv("PATH")
+	for _, p := range filepath.SplitList(path) {
+		if p == dir {
+			return
+		}
+	}
+	if path == "" {
+		os.Setenv("PATH", dir)
+		return
+	}
+	os.Setenv("PATH", path+string(os.PathListSeparator)+dir)
+}
+
+// rcloneVersionRe lee version.txt ("rclone v1.68.2")
+var rcloneVersionRe = regexp.MustCompile(`v\d+\.\d+\.\d+`)
+
+// rcloneArchive devuelve el nombre del zip oficial para este sistema
+// (Ej: rclone-v1.68.2-linux-amd64.zip)
+func rcloneArchive(version, goos, goarch string) (string, error) {
+	osName := goos
+	if goos == "darwin" {
+		osName = "osx"
+	}
+	arch := goarch
+	switch goarch {
+	case "amd64", "arm64", "386", "mips", "mipsle":
+	case "arm":
+		arch = "arm-v7"
+	default:
+		return "", fmt.Errorf("arquitectura no soportada: %s", goarch)
+	}
+	return fmt.Sprintf("rclone-%s-%s-%s.zip", version, osName, arch), nil
+}
+
+// InstallRclone descarga la última versión oficial de rclone, comprueba su
+// SHA256 contra el SHA256SUMS publicado y la deja en ~/.local/bin
+func InstallRclone(progress InstallProgress) error {
+	if progress == nil {
+		progress = func(string, float64) {}
+	}
+
+	progress("Buscando la ultima version...", -1)
+	body, err := fetchText(RcloneBaseURL + "/version.txt")
+	if err != nil {
+		return fmt.Errorf("no se pudo consultar la version de rclone: %v", err)
+	}
+	version := rcloneVersionRe.FindString(body)
+	if version == "" {
+		return fmt.Errorf("version.txt no contiene ninguna version: %q", strings.TrimSpace(body))
+	}
+	archive, err := rcloneArchive(version, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return err
+	}
+
+	progress("Descargando sumas de verificacion...", -1)
+	sums, err := fetchText(RcloneBaseURL + "/" + version + "/SHA256SUMS")
+	if err != nil {
+		return fmt.Errorf("no se pudo descargar SHA256SUMS: %v", err)
+	}
+	want := findChecksum(sums, archive)
+	if want == "" {
+		return fmt.Errorf("SHA256SUMS no incluye %s", archive)
+	}
+
+	tmp, err := os.CreateTemp("", "rclone-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	got, err := downloadWithProgress(RcloneBaseURL+"/"+version+"/"+archive, tmp, func(fraction float64) {
+		progress("Descargando rclone "+version+"...", fraction)
+	})
+	if err != nil {
+		return fmt.Errorf("error descargando %s: %v", archive, err)
+	}
+	if got != want {
+		return fmt.Errorf("la suma SHA256 de %s no coincide (esperada %s, obtenida %s)", archive, want, got)
+	}
+
+	progress("Instalando en "+LocalBinDir()+"...", -1)
+	if err := extractRclone(tmp.Name(), LocalBinDir()); err != nil {
+		return err
+	}
+	AddLocalBinToPath()
+	progress("rclone "+version+" instalado", 1)
+	return nil
+}
+
+func fetchText(url string) (string, error) {
+	resp, err := InstallHTTPClient.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("servidor devolvió %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	return string(data), err
+}
+
+// findChecksum busca el hash de un archivo en SHA256SUMS. El de rclone va
+// firmado con PGP, así que ignoramos todo lo que no sea "hash  nombre".
+func findChecksum(sums, name string) string {
+	scanner := bufio.NewScanner(strings.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name && len(fields[0]) == 64 {
+			if _, err := hex.DecodeString(fields[0]); err == nil {
+				return strings.ToLower(fields[0])
+			}
+		}
+	}
+	return ""
+}
+
+// downloadWithProgress guarda url en out y devuelve su SHA256
+func downloadWithProgress(url string, out io.Writer, onProgress func(float64)) (string, error) {
+	resp, err := InstallHTTPClient.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("servidor devolvió %s", resp.Status)
+	}
+
+	hash := sha256.New()
+	total := resp.ContentLength
+	var done int64
+	buf := make([]byte, 64*1024)
+	for {
+		n, rerr := resp.Body.Read(buf)
+		if n > 0 {
+			if _, err := out.Write(buf[:n]); err != nil {
+				return "", err
+			}
+			hash.Write(buf[:n])
+			done += int64(n)
+			if total > 0 {
+				onProgress(float64(done) / float64(total))
+			} else {
+				onProgress(-1)
+			}
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			return "", rerr
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// extractRclone copia el binario del zip a dir. Se escribe en un temporal
+// y se renombra para no dejar un rclone a medias si algo falla.
+func extractRclone(zipPath, dir string) error {
+	binName := "rclone"
+	if runtime.GOOS == "windows" {
+		binName = "rclone.exe"
+	}
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return fmt.Errorf("zip de rclone no valido: %v", err)
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || filepath.Base(f.Name) != binName {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		src, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+
+		dst := filepath.Join(dir, binName)
+		tmp, err := os.CreateTemp(dir, ".rclone-*")
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(tmp, src); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return err
+		}
+		tmp.Close()
+		if err := os.Chmod(tmp.Name(), 0755); err != nil {
+			os.Remove(tmp.Name())
+			return err
+		}
+		if err := os.Rename(tmp.Name(), dst); err != nil {
+			os.Remove(tmp.Name())
+			return err
+		}
+		return nil
+	}
+	return fmt.Errorf("el zip no contiene %s", binName)
+}
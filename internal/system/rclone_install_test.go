@@ -0,0 +1,132 @@
+package system
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const testVersion = "v1.68.2"
+
+// fakeRcloneDownloads sirve version.txt, SHA256SUMS y el zip como
+// downloads.rclone.org. sum es el hash publicado ("" = el del zip).
+func fakeRcloneDownloads(t *testing.T, binary []byte, sum string) {
+	t.Helper()
+	archive, err := rcloneArchive(testVersion, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		t.Skip(err)
+	}
+	binName := "rclone"
+	if runtime.GOOS == "windows" {
+		binName = "rclone.exe"
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	dir := strings.TrimSuffix(archive, ".zip") + "/"
+	for name, content := range map[string][]byte{
+		dir + "README.txt": []byte("rclone"),
+		dir + binName:      binary,
+	} {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write(content)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zipData := buf.Bytes()
+	if sum == "" {
+		h := sha256.Sum256(zipData)
+		sum = hex.EncodeToString(h[:])
+	}
+	sums := "-----BEGIN PGP SIGNED MESSAGE-----\nHash: SHA1\n\n" +
+		strings.Repeat("0", 64) + "  rclone-" + testVersion + "-otro-sistema.zip\n" +
+		sum + "  " + archive + "\n" +
+		"-----BEGIN PGP SIGNATURE-----\n\nxyz\n-----END PGP SIGNATURE-----\n"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/version.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("rclone " + testVersion + "\n"))
+	})
+	mux.HandleFunc("/"+testVersion+"/SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(sums))
+	})
+	mux.HandleFunc("/"+testVersion+"/"+archive, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(zipData)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	old := RcloneBaseURL
+	RcloneBaseURL = srv.URL
+	t.Cleanup(func() { RcloneBaseURL = old })
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("PATH", "/usr/bin:/bin")
+}
+
+func TestInstallRclone(t *testing.T) {
+	binary := []byte("#!/bin/sh\necho rclone " + testVersion + "\n")
+	fakeRcloneDownloads(t, binary, "")
+
+	var last float64
+	err := InstallRclone(func(status string, fraction float64) { last = fraction })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last != 1 {
+		t.Fatalf("el progreso terminó en %v, esperaba 1", last)
+	}
+
+	dst := filepath.Join(LocalBinDir(), "rclone")
+	if runtime.GOOS == "windows" {
+		dst += ".exe"
+	}
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, binary) {
+		t.Fatalf("el binario instalado no es el del zip: %q", got)
+	}
+	if info, _ := os.Stat(dst); info.Mode().Perm()&0100 == 0 {
+		t.Fatalf("el binario no es ejecutable: %v", info.Mode())
+	}
+	if want := "/usr/bin:/bin" + string(os.PathListSeparator) + LocalBinDir(); os.Getenv("PATH") != want {
+		t.Fatalf("PATH = %q, esperaba %q", os.Getenv("PATH"), want)
+	}
+}
+
+func TestInstallRcloneChecksumMismatch(t *testing.T) {
+	fakeRcloneDownloads(t, []byte("binario manipulado"), strings.Repeat("ab", 32))
+
+	err := InstallRclone(nil)
+	if err == nil || !strings.Contains(err.Error(), "no coincide") {
+		t.Fatalf("esperaba error de suma SHA256, obtuve %v", err)
+	}
+	if _, err := os.Stat(LocalBinDir()); !os.IsNotExist(err) {
+		t.Fatal("no debía instalarse nada si la suma no coincide")
+	}
+}
+
+func TestAddLocalBinToPath(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("PATH", "/usr/local/bin:/usr/bin")
+
+	AddLocalBinToPath()
+	AddLocalBinToPath()
+	want := "/usr/local/bin:/usr/bin" + string(os.PathListSeparator) + LocalBinDir()
+	if got := os.Getenv("PATH"); got != want {
+		t.Fatalf("PATH = %q, esperaba %q (al final y una sola vez)", got, want)
+	}
+}